@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/Damien-Venant/prev-updater/internal/infra"
 	"github.com/Damien-Venant/prev-updater/internal/repository"
@@ -16,6 +18,11 @@ import (
 const (
 	EXIT_FAILURE = -1
 	EXIT_SUCCESS = 0
+
+	n8nSecretEnv      = "PREV_UPDATER_N8N_SECRET"
+	githubTokenEnv    = "PREV_UPDATER_GITHUB_TOKEN"
+	bitbucketTokenEnv = "PREV_UPDATER_BITBUCKET_TOKEN"
+	defaultBranchEnv  = "PREV_UPDATER_DEFAULT_BRANCH"
 )
 
 var (
@@ -27,8 +34,57 @@ var (
 	pipelineId   int32
 	repositoryId string = ""
 	fieldName    string = ""
-	branchName   string = ""
+	branchNames  []string
+	branchMatch  string = ""
 	n8nUrl       string = ""
+	webhookUrl   string = ""
+	slackUrl     string = ""
+	teamsUrl     string = ""
+
+	n8nHeaders    []string
+	n8nSecret     string = ""
+	n8nRetries    int
+	n8nRetryDelay time.Duration
+	parentType    string = ""
+	rollupTypes   []string
+	workItemIds   []int
+	typesToRoll   []string
+	tagPrefix     string = ""
+	versionTag    bool
+	removeOlder   bool
+	setMappings   []string
+
+	integrationBuild  usescases.IntegrationBuildCodec
+	comment           bool
+	commentTemplate   string = ""
+	commentMention    bool
+	payloadFields     []string
+	discover          bool
+	workItemSources   []string
+	stage             usescases.StageParams
+	runFilter         usescases.RunFilter
+	runRange          usescases.RunRange
+	since             string = ""
+	until             string = ""
+	discoverLink      bool
+	discoverHash      bool
+	baselines         []string
+	baselineTag       string = ""
+	catchUp           bool
+	upstreamDepth     int
+	pathFilters       []string
+	environmentFields []string
+	defaultBranch     string = ""
+	githubToken       string = ""
+	bitbucketToken    string = ""
+
+	configDirectory string = ""
+	noOutbox        bool
+
+	cloudEventsMode       string = ""
+	cloudEventsSource     string = ""
+	cloudEventsType       string = ""
+	cloudEventsDataSchema string = ""
 
 	logger *zerolog.Logger = nil
 )
@@ -53,28 +109,73 @@ var launchCommand = &cobra.Command{
 	Run:   funcStartBatching,
 }
 
+var rollupCommand = &cobra.Command{
+	Use:   "rollup",
+	Short: "Roll up versions on parents",
+	Long:  "Set the version field of Features and Epics from the highest version of their children",
+	Run:   funcRollup,
+}
+
 func init() {
-	launchCommand.Flags().StringVarP(&token, "token", "t", "", "set ADO token (required)")
-	launchCommand.Flags().StringVarP(&baseUrl, "base-url", "b", "https://dev.azure.com/", "set base url")
-	launchCommand.Flags().StringVarP(&organisation, "organisation", "o", "", "set organisation")
+	addAdoFlags(launchCommand)
 	launchCommand.Flags().Int32VarP(&pipelineId, "pipeline-id", "i", 0, "set pipeline id")
-	launchCommand.Flags().StringVarP(&project, "project", "p", "", "project name")
-	launchCommand.Flags().StringVarP(&repositoryId, "repository", "r", "", "set repository id")
-	launchCommand.Flags().StringVarP(&fieldName, "field", "f", "", "set field name")
-	launchCommand.Flags().StringVarP(&branchName, "branch-name", "", "", "set branch name")
-	launchCommand.Flags().StringVarP(&n8nUrl, "n8n-url", "", "", "set n8n url")
-
-	launchCommand.MarkFlagRequired("token")
-	launchCommand.MarkFlagRequired("organisation")
-	launchCommand.MarkFlagRequired("project")
+	launchCommand.Flags().StringVarP(&repositoryId, "repository", "r", "", "set the Azure Repos repository id, read from the runs when it is empty")
+	launchCommand.Flags().StringArrayVarP(&branchNames, "branch-name", "", []string{}, "set branch name, each branch is updated from its own last run (repeatable)")
+	launchCommand.Flags().StringVarP(&branchMatch, "branch-match", "", usescases.BranchMatchExact, "match the branch names: exact, glob (release/*) or regex")
+	addNotifierFlags(launchCommand)
+	launchCommand.Flags().StringVarP(&parentType, "parent-type", "", "", "also update the first ancestor of this work item type (e.g. \"User Story\")")
+	launchCommand.Flags().StringSliceVarP(&rollupTypes, "rollup-type", "", []string{}, "roll up the version on ancestors of these work item types (e.g. Feature,Epic)")
+	launchCommand.Flags().BoolVarP(&versionTag, "tag", "", false, "add the version as a work item tag")
+	launchCommand.Flags().StringVarP(&tagPrefix, "tag-prefix", "", "", "set the prefix of the version tag (e.g. \"v:\")")
+	launchCommand.Flags().BoolVarP(&removeOlder, "tag-remove-older", "", false, "remove the version tags with the same prefix and a lower version")
+	launchCommand.Flags().StringArrayVarP(&setMappings, "set", "", []string{}, "set a field from a template, e.g. 'Custom.IntegratedOn:date={{.Run.FinishedDate}}' (repeatable)")
+	launchCommand.Flags().StringVarP(&integrationBuild.FieldName, "integration-field", "", usescases.AdoIntegrationBuildFieldName, "set the field of the versions history")
+	launchCommand.Flags().StringVarP(&integrationBuild.Separator, "integration-separator", "", usescases.DefaultIntegrationBuildSeparator, "set the separator of the versions history")
+	launchCommand.Flags().StringVarP(&integrationBuild.Sort, "integration-sort", "", usescases.IntegrationBuildSortNone, "sort the versions history: none, asc or desc")
+	launchCommand.Flags().IntVarP(&integrationBuild.MaxEntries, "integration-max-entries", "", 0, "keep only the last N versions in the history (0 for no limit)")
+	launchCommand.Flags().IntVarP(&integrationBuild.MaxLength, "integration-max-length", "", usescases.AdoStringFieldMaxLength, "maximum length of the versions history")
+	launchCommand.Flags().BoolVarP(&comment, "comment", "", false, "add a discussion comment on each updated work item")
+	launchCommand.Flags().StringVarP(&commentTemplate, "comment-template", "", "", "set the Markdown template of the comment")
+	launchCommand.Flags().BoolVarP(&commentMention, "comment-mention", "", false, "mention the assignee in the comment")
+	launchCommand.Flags().StringSliceVarP(&runFilter.Results, "run-result", "", []string{"succeeded"}, "only use the runs with these results, empty for any result")
+	launchCommand.Flags().StringSliceVarP(&runFilter.Reasons, "run-reason", "", []string{usescases.AdoIndividualCIReason, usescases.AdoBatchedCIReason}, "only use the runs triggered for these reasons (e.g. manual, schedule, pullRequest), empty for any reason")
+	launchCommand.Flags().StringSliceVarP(&runFilter.Refs, "run-ref", "", []string{usescases.AdoBranchRefs}, "only use the runs on these refs, a trailing * matches a prefix, empty for any ref")
+	launchCommand.Flags().StringVarP(&runFilter.Reruns, "run-reruns", "", usescases.RerunsOldest, "keep one run by source commit, the oldest or the newest whose name is the version, empty for every run")
+	launchCommand.Flags().IntVarP(&runRange.FromRunId, "from-run", "", 0, "use this run as baseline instead of the previous run")
+	launchCommand.Flags().IntVarP(&runRange.ToRunId, "to-run", "", 0, "use this run instead of the last run")
+	launchCommand.Flags().StringVarP(&since, "since", "", "", "use the runs since this date (2006-01-02 or RFC 3339)")
+	launchCommand.Flags().StringVarP(&until, "until", "", "", "use the runs until this date (2006-01-02 or RFC 3339)")
+	launchCommand.Flags().StringSliceVarP(&baselines, "baseline", "", []string{usescases.BaselineSameRef, usescases.BaselineDefaultBranch}, "find the baseline run with these strategies, tried in order: same-ref, default-branch, lower-version, merge-base, tag")
+	launchCommand.Flags().StringVarP(&baselineTag, "baseline-tag", "", "", "set the build tag of the baseline run for the tag strategy (e.g. release)")
+	launchCommand.Flags().StringVarP(&defaultBranch, "default-branch", "", "", "set the default branch of the repository instead of reading it, e.g. main (default $PREV_UPDATER_DEFAULT_BRANCH)")
+	launchCommand.Flags().StringVarP(&githubToken, "github-token", "", "", "set the token reading the GitHub repositories, or "+githubTokenEnv)
+	launchCommand.Flags().StringVarP(&bitbucketToken, "bitbucket-token", "", "", "set the token reading the Bitbucket repositories, or "+bitbucketTokenEnv)
+	launchCommand.Flags().BoolVarP(&catchUp, "catch-up", "", false, "process every run since the last processed run, kept in the config directory, instead of only the last run")
+	launchCommand.Flags().StringVarP(&stage.Stage, "stage", "", "", "only use the runs whose stage succeeded (name or identifier)")
+	launchCommand.Flags().StringVarP(&stage.Environment, "environment", "", "", "only use the runs deployed to this environment")
+	launchCommand.Flags().StringArrayVarP(&environmentFields, "environment-field", "", []string{}, "set a field from the last runs deployed to an environment, e.g. 'QA=Custom.DeployedToQA' (repeatable)")
+	launchCommand.Flags().StringSliceVarP(&workItemSources, "work-item-source", "", []string{usescases.WorkItemSourceBuild}, "find the work items linked to the build, to the pull requests merged into the branch, or both: build,pull-requests")
+	launchCommand.Flags().StringArrayVarP(&pathFilters, "path-filter", "", []string{}, "keep only the work items of the commits changing these paths, e.g. 'services/api/**', a leading ! excludes the paths (repeatable)")
+	launchCommand.Flags().IntVarP(&upstreamDepth, "upstream-depth", "", 0, "also update the work items of the upstream pipelines consumed by the run (resources.pipelines), followed up to this depth, 0 to ignore them")
+	launchCommand.Flags().BoolVarP(&discover, "discover", "", false, "also update the work items mentioned in the commits (AB#1234) and merged branches (feature/1234-x)")
+	launchCommand.Flags().BoolVarP(&discoverLink, "discover-link", "", false, "link the discovered work items to their commit or pull request")
+	launchCommand.Flags().BoolVarP(&discoverHash, "discover-hash", "", false, "also take the bare #1234 mentions of the commits as work items, not only AB#1234")
+	launchCommand.Flags().StringArrayVarP(&payloadFields, "payload-field", "", []string{}, "add a work item field to the notifications, e.g. 'priority=Microsoft.VSTS.Common.Priority' (repeatable)")
+
 	launchCommand.MarkFlagRequired("pipeline-id")
-	launchCommand.MarkFlagRequired("repository")
-	launchCommand.MarkFlagRequired("field")
+
+	addAdoFlags(rollupCommand)
+	rollupCommand.Flags().IntSliceVarP(&workItemIds, "work-item", "w", []int{}, "work items to roll up, with their ancestors")
+	rollupCommand.Flags().StringSliceVarP(&typesToRoll, "rollup-type", "", []string{"Feature", "Epic"}, "work item types to roll up")
+	rollupCommand.MarkFlagRequired("work-item")
+	rollupCommand.MarkFlagRequired("field")
 
 	rootCommand.AddCommand(versionCommand)
 	rootCommand.AddCommand(launchCommand)
+	rootCommand.AddCommand(rollupCommand)
 
-	_, err := infra.ConfigDirectory()
+	var err error
+	configDirectory, err = infra.ConfigDirectory()
 	if err != nil {
 		panic(err)
 	}
@@ -85,6 +186,36 @@ func init() {
 	logger = infra.NewLogger(loggerWriter)
 }
 
+// addAdoFlags adds the flags needed to reach ADO and the version field
+func addAdoFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&token, "token", "t", "", "set ADO token (required)")
+	command.Flags().StringVarP(&baseUrl, "base-url", "b", "https://dev.azure.com/", "set base url")
+	command.Flags().StringVarP(&organisation, "organisation", "o", "", "set organisation")
+	command.Flags().StringVarP(&project, "project", "p", "", "project name")
+	command.Flags().StringVarP(&fieldName, "field", "f", "", "set field name")
+
+	command.MarkFlagRequired("token")
+	command.MarkFlagRequired("organisation")
+	command.MarkFlagRequired("project")
+}
+
+// addNotifierFlags adds the flags of the notification sinks
+func addNotifierFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&n8nUrl, "n8n-url", "", "", "set n8n url")
+	command.Flags().StringArrayVarP(&n8nHeaders, "n8n-header", "", []string{}, "add a header to the n8n requests, e.g. 'Authorization: Bearer xxx' (repeatable)")
+	command.Flags().StringVarP(&n8nSecret, "n8n-secret", "", "", "sign the n8n requests with HMAC-SHA256 (default $PREV_UPDATER_N8N_SECRET)")
+	command.Flags().IntVarP(&n8nRetries, "n8n-attempts", "", 3, "set the number of n8n delivery attempts")
+	command.Flags().DurationVarP(&n8nRetryDelay, "n8n-retry-delay", "", 2*time.Second, "set the delay before the first n8n retry, doubled after each attempt")
+	command.Flags().StringVarP(&webhookUrl, "webhook-url", "", "", "set a generic JSON webhook url")
+	command.Flags().StringVarP(&slackUrl, "slack-webhook-url", "", "", "set a Slack incoming webhook url")
+	command.Flags().StringVarP(&teamsUrl, "teams-webhook-url", "", "", "set a Microsoft Teams webhook url")
+	command.Flags().StringVarP(&cloudEventsMode, "cloudevents", "", "", "wrap the n8n and webhook payloads in CloudEvents 1.0: structured or binary")
+	command.Flags().StringVarP(&cloudEventsSource, "cloudevents-source", "", "", "set the source prefix of the events (default /<organisation>/<project>)")
+	command.Flags().StringVarP(&cloudEventsType, "cloudevents-type", "", repository.VersionIntegratedEventType, "set the type of the events")
+	command.Flags().StringVarP(&cloudEventsDataSchema, "cloudevents-dataschema", "", repository.VersionIntegratedSchema, "set the dataschema of the events")
+	command.Flags().BoolVarP(&noOutbox, "no-outbox", "", false, "deliver the notifications without keeping them in the outbox")
+}
+
 func Execute() {
 	if err := rootCommand.Execute(); err != nil {
 		os.Exit(exitWithError())
@@ -100,25 +231,145 @@ func funcRun(cmd *cobra.Command, args []string) {
 
 }
 
-func funcStartBatching(cmd *cobra.Command, args []string) {
+func newAdoRepository() *repository.AzureDevOpsRepository {
 	url := fmt.Sprintf("%s/%s/%s/", baseUrl, organisation, project)
 	infra.ConfigureHttpClient(&infra.HttpClientConfiguration{
 		BaseUrl: url,
 		Token:   token,
 	}, logger)
-	client := infra.GetHttpClient()
-	n8nClient := httpclient.New(n8nUrl, http.Header{}, logger)
-	n8nRepo := repository.NewN8nRepository(*n8nClient)
-	repo := repository.NewAdoRepository(client)
+	return repository.NewAdoRepository(infra.GetHttpClient())
+}
+
+func funcStartBatching(cmd *cobra.Command, args []string) {
+	repo := newAdoRepository()
 
-	use := usescases.NewAdoUsesCases(repo, n8nRepo, logger)
+	use := usescases.NewAdoUsesCases(repo, newNotifiers(), newOutbox(), logger)
+	use.SourceHosts = newSourceHosts()
+	if catchUp {
+		state, err := repository.NewRunStateRepository(configDirectory)
+		if err != nil {
+			logger.Error().Err(err).Msg("Run state unavailable")
+			os.Exit(exitWithError())
+		}
+		use.State = state
+	}
+
+	if err := integrationBuild.Validate(); err != nil {
+		logger.Error().
+			Err(err).
+			Str("separator", integrationBuild.Separator).
+			Str("sort", integrationBuild.Sort).
+			Msg("Invalid versions history")
+		os.Exit(exitWithError())
+	}
 
-	if err := use.UpdateFieldsByLastRuns(usescases.UpdateFieldsParams{
+	var tags *usescases.TagParams = nil
+	if versionTag || tagPrefix != "" {
+		tags = &usescases.TagParams{
+			Prefix:      tagPrefix,
+			RemoveOlder: removeOlder,
+		}
+	}
+
+	mappings := make([]usescases.FieldMapping, 0, len(setMappings))
+	for _, setMapping := range setMappings {
+		mapping, err := usescases.ParseFieldMapping(setMapping)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("mapping", setMapping).
+				Msg("ParseFieldMapping")
+			os.Exit(exitWithError())
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	var commentParams *usescases.CommentParams = nil
+	if comment {
+		tmpl, err := usescases.ParseCommentTemplate(commentTemplate)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("ParseCommentTemplate")
+			os.Exit(exitWithError())
+		}
+		commentParams = &usescases.CommentParams{
+			Template:        tmpl,
+			MentionAssignee: commentMention,
+		}
+	}
+
+	var err error
+	if runRange.Since, err = parseDate(since); err != nil {
+		logger.Error().Err(err).Str("since", since).Msg("Invalid date")
+		os.Exit(exitWithError())
+	}
+	if runRange.Until, err = parseDate(until); err != nil {
+		logger.Error().Err(err).Str("until", until).Msg("Invalid date")
+		os.Exit(exitWithError())
+	}
+
+	if defaultBranch == "" {
+		defaultBranch = os.Getenv(defaultBranchEnv)
+	}
+
+	var pathFilter *usescases.PathFilter = nil
+	if len(pathFilters) > 0 {
+		if pathFilter, err = usescases.ParsePathFilter(pathFilters); err != nil {
+			logger.Error().
+				Err(err).
+				Strs("path-filter", pathFilters).
+				Msg("ParsePathFilter")
+			os.Exit(exitWithError())
+		}
+	}
+
+	fields := make([]usescases.EnvironmentField, 0, len(environmentFields))
+	for _, environmentField := range environmentFields {
+		field, err := usescases.ParseEnvironmentField(environmentField)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("environment-field", environmentField).
+				Msg("ParseEnvironmentField")
+			os.Exit(exitWithError())
+		}
+		fields = append(fields, field)
+	}
+
+	var discovery *usescases.DiscoveryParams = nil
+	if discover || discoverLink || discoverHash {
+		discovery = &usescases.DiscoveryParams{
+			LinkMissing:  discoverLink,
+			HashMentions: discoverHash,
+		}
+	}
+
+	if err := use.UpdateFieldsByEnvironments(usescases.UpdateFieldsParams{
 		PipelineId:   int(pipelineId),
 		RepositoryId: repositoryId,
-		BranchName:   branchName,
+		BranchMatch:  branchMatch,
 		FieldName:    fieldName,
-	}); err != nil {
+		ParentType:   parentType,
+		RollupTypes:  rollupTypes,
+		Tags:         tags,
+		Mappings:     mappings,
+
+		IntegrationBuild: integrationBuild,
+		Comment:          commentParams,
+		PayloadFields:    parsePayloadFields(payloadFields),
+		Discovery:        discovery,
+		WorkItemSources:  workItemSources,
+		Stage:            &stage,
+		RunFilter:        runFilter,
+		Range:            &runRange,
+		Baselines:        baselines,
+		BaselineTag:      baselineTag,
+		CatchUp:          catchUp,
+		UpstreamDepth:    upstreamDepth,
+		PathFilter:       pathFilter,
+		DefaultBranch:    defaultBranch,
+	}, branchNames, fields); err != nil {
 		logger.Error().
 			Err(err).
 			Stack().
@@ -128,6 +379,146 @@ func funcStartBatching(cmd *cobra.Command, args []string) {
 	}
 }
 
+// newNotifiers returns a notifier for each configured sink
+func newNotifiers() []usescases.Notifier {
+	if n8nSecret == "" {
+		n8nSecret = os.Getenv(n8nSecretEnv)
+	}
+	encoder := newPayloadEncoder()
+	notifiers := []usescases.Notifier{}
+	if n8nUrl != "" {
+		notifiers = append(notifiers, repository.NewN8nRepository(httpclient.New(n8nUrl, http.Header{}, logger), repository.N8nConfiguration{
+			Headers:     parseHeaders(n8nHeaders),
+			Secret:      n8nSecret,
+			MaxAttempts: n8nRetries,
+			RetryDelay:  n8nRetryDelay,
+			Encoder:     encoder,
+		}))
+	}
+	if webhookUrl != "" {
+		notifiers = append(notifiers, repository.NewWebhookRepository(httpclient.New(webhookUrl, http.Header{}, logger), encoder))
+	}
+	if slackUrl != "" {
+		notifiers = append(notifiers, repository.NewSlackRepository(httpclient.New(slackUrl, http.Header{}, logger)))
+	}
+	if teamsUrl != "" {
+		notifiers = append(notifiers, repository.NewTeamsRepository(httpclient.New(teamsUrl, http.Header{}, logger)))
+	}
+	return notifiers
+}
+
+// newPayloadEncoder returns the CloudEvents encoder when it is enabled, or nil for plain JSON
+func newPayloadEncoder() repository.PayloadEncoder {
+	if cloudEventsMode == "" {
+		return nil
+	}
+	if cloudEventsSource == "" {
+		cloudEventsSource = fmt.Sprintf("/%s/%s", organisation, project)
+	}
+	encoder, err := repository.NewCloudEventsEncoder(cloudEventsMode, cloudEventsSource)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Str("cloudevents", cloudEventsMode).
+			Msg("NewCloudEventsEncoder")
+		os.Exit(exitWithError())
+	}
+	encoder.Type = cloudEventsType
+	encoder.DataSchema = cloudEventsDataSchema
+	return encoder
+}
+
+// newSourceHosts returns the clients of the repositories hosted outside of Azure Repos, by repository type
+func newSourceHosts() map[string]usescases.SourceHost {
+	if githubToken == "" {
+		githubToken = os.Getenv(githubTokenEnv)
+	}
+	if bitbucketToken == "" {
+		bitbucketToken = os.Getenv(bitbucketTokenEnv)
+	}
+	return map[string]usescases.SourceHost{
+		usescases.RepositoryTypeGitHub:    repository.NewGitHubRepository(httpclient.New(repository.GitHubApiUrl, bearerHeader(githubToken), logger)),
+		usescases.RepositoryTypeBitbucket: repository.NewBitbucketRepository(httpclient.New(repository.BitbucketApiUrl, bearerHeader(bitbucketToken), logger)),
+	}
+}
+
+// bearerHeader returns the Authorization header of token, no header without token
+func bearerHeader(token string) http.Header {
+	headers := http.Header{}
+	if token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+	return headers
+}
+
+// newOutbox returns the outbox of the config directory, or nil when it is disabled or unavailable
+func newOutbox() usescases.Outbox {
+	if noOutbox {
+		return nil
+	}
+	outbox, err := repository.NewOutboxRepository(configDirectory)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Outbox unavailable")
+		return nil
+	}
+	return outbox
+}
+
+// parsePayloadFields parses mappings written as 'key=Field.ReferenceName', the invalid ones are ignored
+func parsePayloadFields(values []string) map[string]string {
+	fields := make(map[string]string, len(values))
+	for _, value := range values {
+		key, field, ok := strings.Cut(value, "=")
+		if !ok || key == "" || field == "" {
+			logger.Warn().Str("payload-field", value).Msg("Invalid payload field ignored, expected 'key=Field'")
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// parseDate parses a date like 2006-01-02 in local time, or a RFC 3339 time, the zero time is returned for an empty value
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseHeaders parses headers written as 'Name: value', the invalid ones are ignored
+func parseHeaders(values []string) http.Header {
+	headers := http.Header{}
+	for _, value := range values {
+		name, headerValue, ok := strings.Cut(value, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			logger.Warn().Msg("Invalid header ignored, expected 'Name: value'")
+			continue
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+	}
+	return headers
+}
+
+func funcRollup(cmd *cobra.Command, args []string) {
+	use := usescases.NewAdoUsesCases(newAdoRepository(), nil, nil, logger)
+
+	if err := use.RollupVersions(usescases.RollupParams{
+		WorkItemIds: workItemIds,
+		FieldName:   fieldName,
+		Types:       typesToRoll,
+	}); err != nil {
+		logger.Error().
+			Err(err).
+			Stack().
+			Msg("RollupVersions")
+		os.Exit(exitWithError())
+	}
+}
+
 func exitWithError() int {
 	infra.CloseLogFile()
 	return EXIT_FAILURE
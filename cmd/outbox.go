@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/usescases"
+	"github.com/spf13/cobra"
+)
+
+var outboxCommand = &cobra.Command{
+	Use:   "outbox",
+	Short: "Manage the notifications outbox",
+	Long:  "Manage the notifications kept in the outbox of the config directory",
+}
+
+var outboxListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the notifications of the outbox",
+	Long:  "List the notifications of the outbox with their delivery status",
+	Run:   funcOutboxList,
+}
+
+var outboxReplayCommand = &cobra.Command{
+	Use:   "replay",
+	Short: "Deliver the pending notifications",
+	Long:  "Deliver again the pending notifications of the outbox to their configured sink",
+	Run:   funcOutboxReplay,
+}
+
+var outboxPurgeCommand = &cobra.Command{
+	Use:   "purge",
+	Short: "Remove the pending notifications",
+	Long:  "Remove every notification of the outbox, they are not delivered anymore",
+	Run:   funcOutboxPurge,
+}
+
+func init() {
+	addNotifierFlags(outboxReplayCommand)
+
+	outboxCommand.AddCommand(outboxListCommand)
+	outboxCommand.AddCommand(outboxReplayCommand)
+	outboxCommand.AddCommand(outboxPurgeCommand)
+	rootCommand.AddCommand(outboxCommand)
+}
+
+func funcOutboxList(cmd *cobra.Command, args []string) {
+	use := usescases.NewAdoUsesCases(nil, nil, newOutbox(), logger)
+	entries, err := use.ListOutbox()
+	if err != nil {
+		logger.Error().Err(err).Msg("ListOutbox")
+		os.Exit(exitWithError())
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tSINK\tVERSION\tCREATED\tATTEMPTS\tLAST ERROR")
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			entry.Id,
+			entry.Sink,
+			entry.Event.Version,
+			entry.CreatedAt.Local().Format(time.DateTime),
+			entry.Attempts,
+			entry.LastError,
+		)
+	}
+	writer.Flush()
+}
+
+func funcOutboxReplay(cmd *cobra.Command, args []string) {
+	use := usescases.NewAdoUsesCases(nil, newNotifiers(), newOutbox(), logger)
+	results, err := use.ReplayOutbox()
+	if err != nil {
+		logger.Error().Err(err).Msg("ReplayOutbox")
+		os.Exit(exitWithError())
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	fmt.Printf("%d notification(s) replayed, %d failed\n", len(results), failed)
+	if failed > 0 {
+		os.Exit(exitWithError())
+	}
+}
+
+func funcOutboxPurge(cmd *cobra.Command, args []string) {
+	use := usescases.NewAdoUsesCases(nil, nil, newOutbox(), logger)
+	count, err := use.PurgeOutbox()
+	if err != nil {
+		logger.Error().Err(err).Msg("PurgeOutbox")
+		os.Exit(exitWithError())
+	}
+	fmt.Printf("%d notification(s) removed\n", count)
+}
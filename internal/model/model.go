@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type (
 	PaginatedValue[T any] struct {
 		Count int `json:"count"`
@@ -7,23 +9,153 @@ type (
 	}
 
 	PipelineRuns struct {
-		Id        int    `json:"id"`
-		Name      string `json:"name"`
-		State     string `json:"state"`
-		Result    string `json:"result"`
-		Resources *struct {
-			Repositories *struct {
-				Self struct {
-					RefName string `json:"refName"`
-					Version string `json:"Version"`
-				} `json:"self"`
-			} `json:"repositories"`
-		} `json:"resources"`
+		Id           int                `json:"id"`
+		Name         string             `json:"name"`
+		State        string             `json:"state"`
+		Result       string             `json:"result"`
+		CreatedDate  time.Time          `json:"createdDate"`
+		FinishedDate time.Time          `json:"finishedDate"`
+		Links        RunLinks           `json:"_links"`
+		Pipeline     *PipelineReference `json:"pipeline,omitempty"`
+		// Reason, TriggerInfo and Tags are only set by the builds API, see Build
+		Reason      string            `json:"reason,omitempty"`
+		TriggerInfo map[string]string `json:"triggerInfo,omitempty"`
+		Tags        []string          `json:"tags,omitempty"`
+		Resources   *RunResources     `json:"resources"`
+	}
+
+	// RunResources are the resources consumed by a run, by alias, the repository of the pipeline is self
+	RunResources struct {
+		Repositories map[string]RepositoryResource `json:"repositories"`
+		Pipelines    map[string]PipelineResource   `json:"pipelines,omitempty"`
+		Containers   map[string]ContainerResource  `json:"containers,omitempty"`
+	}
+
+	RepositoryResource struct {
+		RefName    string         `json:"refName"`
+		Version    string         `json:"version"`
+		Repository *RunRepository `json:"repository,omitempty"`
+	}
+
+	// PipelineResource is a pipeline whose run is consumed, Version is the name of this run
+	PipelineResource struct {
+		Pipeline PipelineReference `json:"pipeline"`
+		Version  string            `json:"version"`
+	}
+
+	ContainerResource struct {
+		Container struct {
+			Image string `json:"image"`
+		} `json:"container"`
+	}
+
+	// Build is a run as seen by the builds API, with the reason that triggered it
+	Build struct {
+		Id           int               `json:"id"`
+		BuildNumber  string            `json:"buildNumber"`
+		Status       string            `json:"status"`
+		Result       string            `json:"result"`
+		Reason       string            `json:"reason"`
+		SourceBranch string            `json:"sourceBranch"`
+		TriggerInfo  map[string]string `json:"triggerInfo"`
+		Tags         []string          `json:"tags"`
+	}
+
+	// RunRepository is the source repository of a run, Type is azureReposGit, gitHub or bitbucket
+	RunRepository struct {
+		Id       string `json:"id"`
+		Type     string `json:"type"`
+		FullName string `json:"fullName,omitempty"`
+	}
+
+	GitCommitRef struct {
+		CommitId  string        `json:"commitId"`
+		Comment   string        `json:"comment,omitempty"`
+		WorkItems []ResourceRef `json:"workItems,omitempty"`
+	}
+
+	// GitChange is a file changed by a commit, ChangeType is add, edit, delete, rename...
+	GitChange struct {
+		ChangeType string  `json:"changeType"`
+		Item       GitItem `json:"item"`
+	}
+
+	GitItem struct {
+		Path string `json:"path"`
+	}
+
+	ResourceRef struct {
+		Id  string `json:"id"`
+		Url string `json:"url"`
+	}
+
+	PipelineReference struct {
+		Id     int    `json:"id"`
+		Name   string `json:"name"`
+		Folder string `json:"folder,omitempty"`
+		Url    string `json:"url,omitempty"`
+	}
+
+	RunLinks struct {
+		Web Link `json:"web"`
+	}
+
+	Link struct {
+		Href string `json:"href"`
 	}
 
 	BuildChanges struct {
-		Id      string `json:"id"`
-		Message string `json:"message"`
+		Id               string      `json:"id"`
+		Message          string      `json:"message"`
+		MessageTruncated bool        `json:"messageTruncated"`
+		Type             string      `json:"type"`
+		Author           IdentityRef `json:"author"`
+		Timestamp        time.Time   `json:"timestamp"`
+		DisplayUri       string      `json:"displayUri"`
+	}
+
+	IdentityRef struct {
+		Id          string `json:"id"`
+		DisplayName string `json:"displayName"`
+		UniqueName  string `json:"uniqueName"`
+	}
+
+	Timeline struct {
+		Records []TimelineRecord `json:"records"`
+	}
+
+	// TimelineRecord is a stage, a job or a task of a run
+	TimelineRecord struct {
+		Id         string `json:"id"`
+		ParentId   string `json:"parentId"`
+		Type       string `json:"type"`
+		Name       string `json:"name"`
+		Identifier string `json:"identifier"`
+		State      string `json:"state"`
+		Result     string `json:"result"`
+	}
+
+	Environment struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// EnvironmentDeploymentRecord is the deployment of a stage of a run to an environment
+	EnvironmentDeploymentRecord struct {
+		Id            int       `json:"id"`
+		EnvironmentId int       `json:"environmentId"`
+		StageName     string    `json:"stageName"`
+		JobName       string    `json:"jobName"`
+		Result        string    `json:"result"`
+		FinishTime    time.Time `json:"finishTime"`
+		Definition    struct {
+			Id   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"definition"`
+		Owner struct {
+			Id   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"owner"`
 	}
 
 	BuildWorkItems struct {
@@ -31,34 +163,122 @@ type (
 		Url string `json:"url"`
 	}
 	WorkItem struct {
-		Id     int                    `json:"id"`
-		Fields map[string]interface{} `json:"fields"`
+		Id        int                    `json:"id"`
+		Url       string                 `json:"url,omitempty"`
+		Fields    map[string]interface{} `json:"fields"`
+		Relations []WorkItemRelation     `json:"relations,omitempty"`
+	}
+
+	WorkItemRelation struct {
+		Rel        string                 `json:"rel"`
+		Url        string                 `json:"url"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+
+	WorkItemComment struct {
+		Id   int    `json:"id"`
+		Text string `json:"text"`
 	}
 
+	WorkItemComments struct {
+		TotalCount        int               `json:"totalCount"`
+		ContinuationToken string            `json:"continuationToken"`
+		Comments          []WorkItemComment `json:"comments"`
+	}
+
+	// OperationFields is a json-patch operation, Value can be a string, a number or a time.Time
 	OperationFields struct {
-		Op    string `json:"op"`
-		Path  string `json:"path"`
-		Value string `json:"value"`
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
 	}
 
 	Repository struct {
-		Id            string `json:"id"`
-		Name          string `json:"name"`
-		DefaultBranch string `json:"defaultBranch"`
-		Url           string `json:"url"`
-		RemoteUrl     string `json:"remoteUrl"`
+		Id            string           `json:"id"`
+		Name          string           `json:"name"`
+		DefaultBranch string           `json:"defaultBranch"`
+		Url           string           `json:"url"`
+		RemoteUrl     string           `json:"remoteUrl"`
+		Project       ProjectReference `json:"project"`
+	}
+
+	ProjectReference struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	PullRequest struct {
+		PullRequestId   int       `json:"pullRequestId"`
+		Title           string    `json:"title"`
+		Status          string    `json:"status"`
+		SourceRefName   string    `json:"sourceRefName"`
+		TargetRefName   string    `json:"targetRefName"`
+		ClosedDate      time.Time `json:"closedDate"`
+		LastMergeCommit struct {
+			CommitId string `json:"commitId"`
+		} `json:"lastMergeCommit"`
 	}
 
 	N8nResult struct {
-		Version      string         `json:"version"`
-		SourceBranch string         `json:"source-branch"`
-		WorkItems    []N8NWorkItems `json:"work-items"`
+		Version        string             `json:"version"`
+		SourceBranch   string             `json:"source-branch"`
+		PipelineId     int                `json:"pipeline-id,omitempty"`
+		Pipeline       *PipelineReference `json:"pipeline,omitempty"`
+		RepositoryId   string             `json:"repository-id,omitempty"`
+		RunId          int                `json:"run-id,omitempty"`
+		RunUrl         string             `json:"run-url,omitempty"`
+		BaselineRunId  int                `json:"baseline-run-id,omitempty"`
+		BaselineRunUrl string             `json:"baseline-run-url,omitempty"`
+		CommitRange    *CommitRange       `json:"commit-range,omitempty"`
+		Commits        []N8nCommit        `json:"commits,omitempty"`
+		WorkItems      []N8NWorkItems     `json:"work-items"`
+	}
+
+	N8nCommit struct {
+		Id        string    `json:"id"`
+		Message   string    `json:"message"`
+		Author    string    `json:"author,omitempty"`
+		Email     string    `json:"email,omitempty"`
+		Timestamp time.Time `json:"timestamp"`
+		Url       string    `json:"url,omitempty"`
+	}
+
+	CommitRange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	// OutboxEntry is a release event waiting to be delivered to a sink
+	OutboxEntry struct {
+		Id        string    `json:"id"`
+		Sink      string    `json:"sink"`
+		CreatedAt time.Time `json:"created-at"`
+		Attempts  int       `json:"attempts"`
+		LastError string    `json:"last-error,omitempty"`
+		Event     N8nResult `json:"event"`
+	}
+
+	// RunCheckpoint is the last run processed on a ref of a pipeline, kept between the invocations
+	RunCheckpoint struct {
+		Key         string    `json:"key"`
+		PipelineId  int       `json:"pipeline-id"`
+		RefName     string    `json:"ref-name"`
+		LastRunId   int       `json:"last-run-id"`
+		LastRunName string    `json:"last-run-name"`
+		UpdatedAt   time.Time `json:"updated-at"`
 	}
 
 	N8NWorkItems struct {
-		Id               int      `json:"id"`
-		Title            string   `json:"title"`
-		Tags             []string `json:"tags"`
-		IntegrationBuild []string `json:"integration-builds"`
+		Id               int                    `json:"id"`
+		Title            string                 `json:"title"`
+		Type             string                 `json:"type,omitempty"`
+		State            string                 `json:"state,omitempty"`
+		AssignedTo       string                 `json:"assigned-to,omitempty"`
+		AreaPath         string                 `json:"area-path,omitempty"`
+		Url              string                 `json:"url,omitempty"`
+		Tags             []string               `json:"tags"`
+		IntegrationBuild []string               `json:"integration-builds"`
+		Fields           map[string]interface{} `json:"fields,omitempty"`
+		Repositories     []string               `json:"repositories,omitempty"`
 	}
 )
@@ -45,3 +45,11 @@ func treatResult(response *http.Response, expectedReturnCode int) error {
 	}
 	return nil
 }
+
+// treatSuccessResult accepts any 2xx status code
+func treatSuccessResult(response *http.Response) error {
+	if returnCode := response.StatusCode; returnCode < 200 || returnCode >= 300 {
+		return errorCodeMapping(returnCode)
+	}
+	return nil
+}
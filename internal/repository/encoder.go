@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	CloudEventsSpecVersion     string = "1.0"
+	CloudEventsStructuredMode  string = "structured"
+	CloudEventsBinaryMode      string = "binary"
+	CloudEventsContentType     string = "application/cloudevents+json"
+	VersionIntegratedEventType string = "com.prev-updater.version.integrated"
+	// VersionIntegratedSchema is the JSON Schema of the data of a version integrated event, see schemas/
+	VersionIntegratedSchema string = "https://github.com/Damien-Venant/prev-updater/blob/main/schemas/version-integrated/v1.json"
+)
+
+var (
+	ErrInvalidCloudEventsMode error = errors.New("invalid CloudEvents mode, expected structured or binary")
+)
+
+type (
+	// PayloadEncoder turns a release event into the body and headers of a webhook request
+	PayloadEncoder interface {
+		Encode(data model.N8nResult) ([]byte, http.Header, error)
+	}
+
+	JsonEncoder struct{}
+
+	// CloudEventsEncoder wraps the release event in a CloudEvents 1.0 envelope
+	CloudEventsEncoder struct {
+		Mode string
+		// Source is prepended to /pipelines/<id>, e.g. /<organisation>/<project>
+		Source     string
+		Type       string
+		DataSchema string
+		now        func() time.Time
+	}
+
+	cloudEvent struct {
+		SpecVersion     string          `json:"specversion"`
+		Type            string          `json:"type"`
+		Source          string          `json:"source"`
+		Id              string          `json:"id"`
+		Time            string          `json:"time"`
+		DataContentType string          `json:"datacontenttype"`
+		DataSchema      string          `json:"dataschema,omitempty"`
+		Data            model.N8nResult `json:"data"`
+	}
+)
+
+func (JsonEncoder) Encode(data model.N8nResult) ([]byte, http.Header, error) {
+	body, err := json.Marshal(data)
+	return body, http.Header{}, err
+}
+
+func NewCloudEventsEncoder(mode string, source string) (*CloudEventsEncoder, error) {
+	if mode != CloudEventsStructuredMode && mode != CloudEventsBinaryMode {
+		return nil, ErrInvalidCloudEventsMode
+	}
+	return &CloudEventsEncoder{
+		Mode:       mode,
+		Source:     source,
+		Type:       VersionIntegratedEventType,
+		DataSchema: VersionIntegratedSchema,
+		now:        time.Now,
+	}, nil
+}
+
+func (e *CloudEventsEncoder) Encode(data model.N8nResult) ([]byte, http.Header, error) {
+	event := cloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Type:            e.Type,
+		Source:          fmt.Sprintf("%s/pipelines/%d", e.Source, data.PipelineId),
+		Id:              idempotencyKey(data),
+		Time:            e.now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		DataSchema:      e.DataSchema,
+		Data:            data,
+	}
+	if event.Id == "" {
+		event.Id = randomId()
+	}
+
+	headers := http.Header{}
+	if e.Mode == CloudEventsStructuredMode {
+		headers.Set("Content-Type", CloudEventsContentType)
+		body, err := json.Marshal(event)
+		return body, headers, err
+	}
+
+	headers.Set("Content-Type", event.DataContentType)
+	headers.Set("ce-specversion", event.SpecVersion)
+	headers.Set("ce-type", event.Type)
+	headers.Set("ce-source", event.Source)
+	headers.Set("ce-id", event.Id)
+	headers.Set("ce-time", event.Time)
+	if event.DataSchema != "" {
+		headers.Set("ce-dataschema", event.DataSchema)
+	}
+	body, err := json.Marshal(data)
+	return body, headers, err
+}
+
+func randomId() string {
+	buffer := make([]byte, 16)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestCloudEventsEncoder(t *testing.T, mode string) *CloudEventsEncoder {
+	encoder, err := NewCloudEventsEncoder(mode, "/org/project")
+	assert.Nil(t, err)
+	encoder.now = func() time.Time { return time.Date(2025, 4, 13, 10, 0, 0, 0, time.UTC) }
+	return encoder
+}
+
+func TestNewCloudEventsEncoder_WithInvalidMode(t *testing.T) {
+	encoder, err := NewCloudEventsEncoder("batch", "/org/project")
+
+	assert.Nil(t, encoder)
+	assert.ErrorIs(t, err, ErrInvalidCloudEventsMode)
+}
+
+func TestCloudEventsEncoder_Structured(t *testing.T) {
+	encoder := newTestCloudEventsEncoder(t, CloudEventsStructuredMode)
+	data := model.N8nResult{Version: "25.4.13", PipelineId: 12, RunId: 42, BaselineRunId: 40}
+
+	body, headers, err := encoder.Encode(data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, CloudEventsContentType, headers.Get("Content-Type"))
+	event := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(body, &event))
+	assert.Equal(t, "1.0", event["specversion"])
+	assert.Equal(t, VersionIntegratedEventType, event["type"])
+	assert.Equal(t, "/org/project/pipelines/12", event["source"])
+	assert.Equal(t, "12-40-42", event["id"])
+	assert.Equal(t, "2025-04-13T10:00:00Z", event["time"])
+	assert.Equal(t, "application/json", event["datacontenttype"])
+	assert.Equal(t, VersionIntegratedSchema, event["dataschema"])
+	assert.Equal(t, "25.4.13", event["data"].(map[string]interface{})["version"])
+}
+
+func TestCloudEventsEncoder_Binary(t *testing.T) {
+	encoder := newTestCloudEventsEncoder(t, CloudEventsBinaryMode)
+	data := model.N8nResult{Version: "25.4.13", PipelineId: 12, RunId: 42, BaselineRunId: 40}
+	expectedBody, _ := json.Marshal(data)
+
+	body, headers, err := encoder.Encode(data)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedBody, body)
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "1.0", headers.Get("ce-specversion"))
+	assert.Equal(t, VersionIntegratedEventType, headers.Get("ce-type"))
+	assert.Equal(t, "/org/project/pipelines/12", headers.Get("ce-source"))
+	assert.Equal(t, "12-40-42", headers.Get("ce-id"))
+	assert.Equal(t, "2025-04-13T10:00:00Z", headers.Get("ce-time"))
+	assert.Equal(t, VersionIntegratedSchema, headers.Get("ce-dataschema"))
+}
+
+func TestCloudEventsEncoder_WithoutRunIdShouldUseRandomId(t *testing.T) {
+	encoder := newTestCloudEventsEncoder(t, CloudEventsBinaryMode)
+
+	_, first, _ := encoder.Encode(model.N8nResult{Version: "25.4.13"})
+	_, second, _ := encoder.Encode(model.N8nResult{Version: "25.4.13"})
+
+	assert.Len(t, first.Get("ce-id"), 32)
+	assert.NotEqual(t, first.Get("ce-id"), second.Get("ce-id"))
+}
+
+func TestPostWebhook_ShouldSignCloudEvent(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	encoder := newTestCloudEventsEncoder(t, CloudEventsStructuredMode)
+	repo, _ := newTestN8nRepository(mockClient, N8nConfiguration{
+		Secret:  "secret",
+		Encoder: encoder,
+	})
+	data := model.N8nResult{Version: "25.4.13", PipelineId: 12, RunId: 42, BaselineRunId: 40}
+	body, _, _ := encoder.Encode(data)
+
+	mockClient.On("Post", "", body, mock.MatchedBy(func(headers http.Header) bool {
+		return headers.Get("Content-Type") == CloudEventsContentType &&
+			headers.Get(SignatureHeader) == Sign("secret", "1700000000", body)
+	})).Return(makeHttpResponse(http.StatusOK, nil), nil)
+
+	err := repo.PostWebhook(data)
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestWebhookNotify_ShouldSendCloudEventHeaders(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewWebhookRepository(mockClient, newTestCloudEventsEncoder(t, CloudEventsBinaryMode))
+
+	mockClient.On("Post", "", mock.Anything, mock.MatchedBy(func(headers http.Header) bool {
+		return headers.Get("ce-type") == VersionIntegratedEventType
+	})).Return(makeHttpResponse(http.StatusOK, nil), nil)
+
+	err := repo.Notify(createN8nResult())
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
@@ -1,36 +1,137 @@
 package repository
 
 import (
-	"encoding/json"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Damien-Venant/prev-updater/internal/model"
 	httpclient "github.com/Damien-Venant/prev-updater/pkg/http-client"
 )
 
-type N8nRepository struct {
-	client httpclient.HttpClient
-}
+const (
+	SignatureHeader      string = "X-Prev-Updater-Signature"
+	TimestampHeader      string = "X-Prev-Updater-Timestamp"
+	IdempotencyKeyHeader string = "Idempotency-Key"
+)
+
+type (
+	N8nRepository struct {
+		client httpclient.HttpClientInterface
+		config N8nConfiguration
+		sleep  func(time.Duration)
+		now    func() time.Time
+	}
+
+	N8nConfiguration struct {
+		// Headers are added to each request, e.g. for the authentication of the webhook
+		Headers http.Header
+		// Secret signs the body with HMAC-SHA256 when it is set
+		Secret string
+		// MaxAttempts is the number of deliveries tried before giving up, at least one
+		MaxAttempts int
+		// RetryDelay is the delay before the first retry, doubled after each attempt
+		RetryDelay time.Duration
+		// Encoder builds the body and headers, the event is sent as plain JSON when it is nil
+		Encoder PayloadEncoder
+	}
+)
 
-func NewN8nRepository(client httpclient.HttpClient) *N8nRepository {
+func NewN8nRepository(client httpclient.HttpClientInterface, config N8nConfiguration) *N8nRepository {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.Encoder == nil {
+		config.Encoder = JsonEncoder{}
+	}
 	return &N8nRepository{
 		client: client,
+		config: config,
+		sleep:  time.Sleep,
+		now:    time.Now,
 	}
 }
 
+func (repo *N8nRepository) Name() string {
+	return "n8n"
+}
+
+func (repo *N8nRepository) Notify(data model.N8nResult) error {
+	return repo.PostWebhook(data)
+}
+
+// PostWebhook delivers data to n8n, retrying on network errors, 429 and 5xx responses
 func (repo *N8nRepository) PostWebhook(data model.N8nResult) error {
-	model, err := json.Marshal(data)
+	body, encodedHeaders, err := repo.config.Encoder.Encode(data)
 	if err != nil {
 		return err
 	}
 
-	httpResponse, err := repo.client.Post("", model, nil)
+	var errMap error = nil
+	delay := repo.config.RetryDelay
+	for attempt := 1; attempt <= repo.config.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			repo.sleep(delay)
+			delay *= 2
+		}
+
+		retry, err := repo.post(body, encodedHeaders, idempotencyKey(data))
+		if err == nil {
+			return nil
+		}
+		errMap = errors.Join(errMap, fmt.Errorf("attempt %d: %w", attempt, err))
+		if !retry {
+			break
+		}
+	}
+	return errMap
+}
+
+func (repo *N8nRepository) post(body []byte, encodedHeaders http.Header, key string) (bool, error) {
+	headers := repo.config.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	for name, values := range encodedHeaders {
+		headers[name] = values
+	}
+	if key != "" {
+		headers.Set(IdempotencyKeyHeader, key)
+	}
+	if repo.config.Secret != "" {
+		timestamp := strconv.FormatInt(repo.now().Unix(), 10)
+		headers.Set(TimestampHeader, timestamp)
+		headers.Set(SignatureHeader, Sign(repo.config.Secret, timestamp, body))
+	}
+
+	httpResponse, err := repo.client.Post("", body, headers)
 	if err != nil {
-		return err
+		return true, err
 	}
+	if err = treatSuccessResult(httpResponse); err != nil {
+		statusCode := httpResponse.StatusCode
+		return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError, err
+	}
+	return false, nil
+}
 
-	if err = treatResult(httpResponse, http.StatusOK); err != nil {
-		return err
+// Sign returns the signature of the body sent at timestamp: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// idempotencyKey identifies the pair of runs of a release event, so that n8n can ignore a replay
+func idempotencyKey(data model.N8nResult) string {
+	if data.RunId == 0 {
+		return ""
 	}
-	return nil
+	return fmt.Sprintf("%d-%d-%d", data.PipelineId, data.BaselineRunId, data.RunId)
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestN8nRepository(client *MockHttpClient, config N8nConfiguration) (*N8nRepository, *[]time.Duration) {
+	delays := []time.Duration{}
+	repo := NewN8nRepository(client, config)
+	repo.sleep = func(delay time.Duration) { delays = append(delays, delay) }
+	repo.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return repo, &delays
+}
+
+func TestSign(t *testing.T) {
+	signature := Sign("secret", "1700000000", []byte(`{"version":"25.4.13"}`))
+
+	assert.Equal(t, "sha256=5397d390b29240d63269296ad002e6bebec7acc05fc327c37ce32974f5951864", signature)
+	assert.NotEqual(t, signature, Sign("secret", "1700000001", []byte(`{"version":"25.4.13"}`)))
+}
+
+func TestPostWebhook_ShouldSignAndAuthenticate(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo, _ := newTestN8nRepository(mockClient, N8nConfiguration{
+		Headers: http.Header{"Authorization": []string{"Bearer token"}},
+		Secret:  "secret",
+	})
+	data := model.N8nResult{Version: "25.4.13", PipelineId: 12, RunId: 42, BaselineRunId: 40}
+	body, _ := json.Marshal(data)
+
+	mockClient.On("Post", "", body, mock.MatchedBy(func(headers http.Header) bool {
+		return headers.Get("Authorization") == "Bearer token" &&
+			headers.Get(TimestampHeader) == "1700000000" &&
+			headers.Get(SignatureHeader) == Sign("secret", "1700000000", body) &&
+			headers.Get(IdempotencyKeyHeader) == "12-40-42"
+	})).Return(makeHttpResponse(http.StatusAccepted, nil), nil)
+
+	err := repo.PostWebhook(data)
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestPostWebhook_ShouldRetryOnServerError(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo, delays := newTestN8nRepository(mockClient, N8nConfiguration{MaxAttempts: 3, RetryDelay: time.Second})
+
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusBadGateway, nil), nil).Once()
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusOK, nil), errors.New("connection reset")).Once()
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusNoContent, nil), nil).Once()
+
+	err := repo.PostWebhook(model.N8nResult{Version: "25.4.13"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+	mockClient.AssertNumberOfCalls(t, "Post", 3)
+}
+
+func TestPostWebhook_ShouldNotRetryOnClientError(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo, delays := newTestN8nRepository(mockClient, N8nConfiguration{MaxAttempts: 3, RetryDelay: time.Second})
+
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusBadRequest, nil), nil)
+
+	err := repo.PostWebhook(model.N8nResult{Version: "25.4.13"})
+
+	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.Empty(t, *delays)
+	mockClient.AssertNumberOfCalls(t, "Post", 1)
+}
+
+func TestPostWebhook_ShouldReturnErrorAfterLastAttempt(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo, _ := newTestN8nRepository(mockClient, N8nConfiguration{MaxAttempts: 2})
+
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusInternalServerError, nil), nil)
+
+	err := repo.PostWebhook(model.N8nResult{Version: "25.4.13"})
+
+	assert.ErrorIs(t, err, ErrInternalServer)
+	mockClient.AssertNumberOfCalls(t, "Post", 2)
+}
+
+func TestIdempotencyKey_WithoutRunId(t *testing.T) {
+	assert.Equal(t, "", idempotencyKey(model.N8nResult{Version: "25.4.13"}))
+}
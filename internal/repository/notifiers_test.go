@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createN8nResult() model.N8nResult {
+	return model.N8nResult{
+		Version:      "25.4.13",
+		SourceBranch: "refs/heads/main",
+		WorkItems: []model.N8NWorkItems{
+			{Id: 1, Title: "First"},
+			{Id: 2, Title: "Second"},
+		},
+	}
+}
+
+func TestWebhookNotify_ShouldPostPayloadAndAcceptAny2xx(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewWebhookRepository(mockClient, nil)
+
+	expectedBody, _ := json.Marshal(createN8nResult())
+	mockClient.On("Post", "", expectedBody, mock.Anything).Return(makeHttpResponse(http.StatusNoContent, nil), nil)
+
+	err := repo.Notify(createN8nResult())
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestWebhookNotify_ShouldReturnErrorOnFailure(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewWebhookRepository(mockClient, nil)
+
+	mockClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusInternalServerError, nil), nil)
+
+	err := repo.Notify(createN8nResult())
+
+	assert.ErrorIs(t, err, ErrInternalServer)
+}
+
+func TestNewSlackMessage(t *testing.T) {
+	message := newSlackMessage(createN8nResult())
+
+	assert.Equal(t, "Version 25.4.13 on refs/heads/main: 2 work item(s)", message.Text)
+	assert.Len(t, message.Blocks, 2)
+	assert.Equal(t, "• #1 First\n• #2 Second", message.Blocks[1].Text.Text)
+}
+
+func TestNewSlackMessage_WithRepositories(t *testing.T) {
+	data := createN8nResult()
+	data.WorkItems[0].Repositories = []string{"self", "tools"}
+
+	message := newSlackMessage(data)
+
+	assert.Equal(t, "• #1 First (self, tools)\n• #2 Second", message.Blocks[1].Text.Text)
+}
+
+func TestNewSlackMessage_ShouldEscapeMrkdwn(t *testing.T) {
+	data := createN8nResult()
+	data.Version = "25.4.13<rc>"
+	data.WorkItems[0].Title = "Fix <script> & co"
+
+	message := newSlackMessage(data)
+
+	assert.Equal(t, "Version 25.4.13&lt;rc&gt; on refs/heads/main: 2 work item(s)", message.Text)
+	assert.Equal(t, "*Version 25.4.13&lt;rc&gt; on refs/heads/main: 2 work item(s)*", message.Blocks[0].Text.Text)
+	assert.Equal(t, "• #1 Fix &lt;script&gt; &amp; co\n• #2 Second", message.Blocks[1].Text.Text)
+}
+
+func TestNewSlackMessage_WithoutWorkItems(t *testing.T) {
+	message := newSlackMessage(model.N8nResult{Version: "25.4.13"})
+
+	assert.Equal(t, "Version 25.4.13: 0 work item(s)", message.Text)
+	assert.Len(t, message.Blocks, 1)
+}
+
+func TestNewTeamsMessage(t *testing.T) {
+	message := newTeamsMessage(createN8nResult())
+
+	assert.Equal(t, "message", message.Type)
+	assert.Len(t, message.Attachments, 1)
+	assert.Equal(t, adaptiveCardContentType, message.Attachments[0].ContentType)
+	card := message.Attachments[0].Content
+	assert.Equal(t, "AdaptiveCard", card.Type)
+	assert.Equal(t, "Version 25.4.13 on refs/heads/main: 2 work item(s)", card.Body[0].Text)
+	assert.Equal(t, []adaptiveCardFact{{Title: "#1", Value: "First"}, {Title: "#2", Value: "Second"}}, card.Body[1].Facts)
+}
+
+func TestSlackAndTeamsNotify(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	mockClient.On("Post", "", mock.Anything, mock.Anything).Return(makeHttpResponse(http.StatusOK, nil), nil)
+
+	assert.Nil(t, NewSlackRepository(mockClient).Notify(createN8nResult()))
+	assert.Nil(t, NewTeamsRepository(mockClient).Notify(createN8nResult()))
+	mockClient.AssertNumberOfCalls(t, "Post", 2)
+}
+
+func TestNewSlackMessage_ShouldSplitLongLists(t *testing.T) {
+	data := model.N8nResult{Version: "25.4.13"}
+	for id := 1; id <= 100; id++ {
+		data.WorkItems = append(data.WorkItems, model.N8NWorkItems{Id: id, Title: strings.Repeat("a", 100)})
+	}
+
+	message := newSlackMessage(data)
+
+	assert.Greater(t, len(message.Blocks), 2)
+	count := 0
+	for _, block := range message.Blocks[1:] {
+		assert.LessOrEqual(t, len(block.Text.Text), slackSectionMaxLength)
+		count += strings.Count(block.Text.Text, "• #")
+	}
+	assert.Equal(t, 100, count)
+}
+
+func TestNewSlackMessage_ShouldCountWorkItemsOverBlockLimit(t *testing.T) {
+	data := model.N8nResult{Version: "25.4.13"}
+	for id := 1; id <= 2000; id++ {
+		data.WorkItems = append(data.WorkItems, model.N8NWorkItems{Id: id, Title: strings.Repeat("a", 100)})
+	}
+
+	message := newSlackMessage(data)
+
+	assert.Len(t, message.Blocks, slackMaxBlocks)
+	count := 0
+	for _, block := range message.Blocks[1:] {
+		assert.LessOrEqual(t, len(block.Text.Text), slackSectionMaxLength)
+		count += strings.Count(block.Text.Text, "• #")
+	}
+	last := message.Blocks[len(message.Blocks)-1].Text.Text
+	assert.True(t, strings.HasSuffix(last, fmt.Sprintf("…and %d more", 2000-count)), last)
+}
+
+func TestSlackSections_ShouldCutLongLine(t *testing.T) {
+	sections := slackSections([]string{strings.Repeat("é", slackSectionMaxLength)}, 1)
+
+	assert.Len(t, sections, 1)
+	assert.LessOrEqual(t, len(sections[0]), slackSectionMaxLength)
+	assert.True(t, utf8.ValidString(sections[0]))
+	assert.True(t, strings.HasSuffix(sections[0], "…"))
+}
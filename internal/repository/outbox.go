@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	outboxDirectoryName string = "outbox"
+	outboxExtension     string = ".json"
+)
+
+var (
+	ErrInvalidOutboxId error = errors.New("invalid outbox entry id")
+)
+
+// OutboxRepository stores the release events as JSON files, one per entry, in the outbox directory
+type OutboxRepository struct {
+	directory string
+}
+
+func NewOutboxRepository(configDirectory string) (*OutboxRepository, error) {
+	directory := path.Join(configDirectory, outboxDirectoryName)
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, err
+	}
+	return &OutboxRepository{
+		directory: directory,
+	}, nil
+}
+
+// Save writes the entry in a temporary file then renames it, so an entry is never half written
+func (repo *OutboxRepository) Save(entry model.OutboxEntry) error {
+	fileName, err := repo.fileName(entry.Id)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := fileName + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, fileName)
+}
+
+// List returns every entry, the oldest first
+func (repo *OutboxRepository) List() ([]model.OutboxEntry, error) {
+	files, err := os.ReadDir(repo.directory)
+	if err != nil {
+		return []model.OutboxEntry{}, err
+	}
+
+	entries := make([]model.OutboxEntry, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), outboxExtension) {
+			continue
+		}
+		content, err := os.ReadFile(path.Join(repo.directory, file.Name()))
+		if err != nil {
+			return []model.OutboxEntry{}, err
+		}
+		var entry model.OutboxEntry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return []model.OutboxEntry{}, err
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortStableFunc(entries, func(a, b model.OutboxEntry) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return entries, nil
+}
+
+func (repo *OutboxRepository) Delete(id string) error {
+	fileName, err := repo.fileName(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (repo *OutboxRepository) fileName(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", ErrInvalidOutboxId
+	}
+	return path.Join(repo.directory, id+outboxExtension), nil
+}
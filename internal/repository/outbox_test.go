@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepository_SaveListDelete(t *testing.T) {
+	repo, err := NewOutboxRepository(t.TempDir())
+	assert.Nil(t, err)
+
+	first := model.OutboxEntry{Id: "1-n8n", Sink: "n8n", CreatedAt: time.Unix(10, 0).UTC(), Event: model.N8nResult{Version: "25.4.1"}}
+	second := model.OutboxEntry{Id: "2-slack", Sink: "slack", CreatedAt: time.Unix(20, 0).UTC(), Event: model.N8nResult{Version: "25.4.13"}}
+	assert.Nil(t, repo.Save(second))
+	assert.Nil(t, repo.Save(first))
+
+	first.Attempts = 1
+	first.LastError = "n8n down"
+	assert.Nil(t, repo.Save(first))
+
+	entries, err := repo.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []model.OutboxEntry{first, second}, entries)
+
+	assert.Nil(t, repo.Delete("1-n8n"))
+	assert.Nil(t, repo.Delete("1-n8n"))
+	entries, err = repo.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []model.OutboxEntry{second}, entries)
+}
+
+func TestOutboxRepository_ShouldIgnoreOtherFiles(t *testing.T) {
+	directory := t.TempDir()
+	repo, _ := NewOutboxRepository(directory)
+	os.WriteFile(path.Join(directory, outboxDirectoryName, "entry.json.tmp"), []byte("{"), 0640)
+
+	entries, err := repo.List()
+
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestOutboxRepository_ShouldRejectInvalidId(t *testing.T) {
+	repo, _ := NewOutboxRepository(t.TempDir())
+
+	assert.ErrorIs(t, repo.Save(model.OutboxEntry{Id: "../config"}), ErrInvalidOutboxId)
+	assert.ErrorIs(t, repo.Delete(""), ErrInvalidOutboxId)
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,7 +16,17 @@ import (
 )
 
 const (
-	apiVersion string = "7.1"
+	apiVersion             string = "7.1"
+	commentsApiVersion     string = "7.1-preview.4"
+	changesApiVersion      string = "7.1-preview.2"
+	environmentsApiVersion string = "7.1-preview.1"
+
+	// buildIdsBatchSize is the number of builds requested at once by id
+	buildIdsBatchSize int = 200
+	// pageSize is the number of commits, changes or pull requests read by page
+	pageSize int = 1000
+	// continuationTokenHeader is the header giving the token of the next page
+	continuationTokenHeader string = "X-Ms-Continuationtoken"
 )
 
 type AzureDevOpsRepository struct {
@@ -101,6 +113,33 @@ func (r *AzureDevOpsRepository) GetPipelineRun(pipelineId, runId int) (*model.Pi
 	return &result, nil
 }
 
+// GetBuildsByIds returns the builds of the ids, with their reason, trigger info and tags
+// The ids are requested by batches, so the url stays short
+func (r *AzureDevOpsRepository) GetBuildsByIds(buildIds []int) ([]model.Build, error) {
+	type Builds model.PaginatedValue[model.Build]
+	result := []model.Build{}
+	for batch := range slices.Chunk(buildIds, buildIdsBatchSize) {
+		var builds Builds
+		ids := make([]string, 0, len(batch))
+		for _, id := range batch {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		url := r.configureRouteWithVersion("build/builds?buildIds=%s", strings.Join(ids, ","))
+		httpResponse, err := r.client.Get(url, nil)
+		if err != nil {
+			return []model.Build{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.Build{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &builds); err != nil {
+			return []model.Build{}, err
+		}
+		result = append(result, builds.Value...)
+	}
+	return result, nil
+}
+
 func (r *AzureDevOpsRepository) GetBuildWorkItem(fromBuildId, toBuildId int) ([]model.BuildWorkItems, error) {
 	type BuildWorkItems model.PaginatedValue[model.BuildWorkItems]
 	var workItem BuildWorkItems
@@ -120,6 +159,33 @@ func (r *AzureDevOpsRepository) GetBuildWorkItem(fromBuildId, toBuildId int) ([]
 	return workItem.Value, nil
 }
 
+// GetBuildChanges returns the commits brought by the builds after fromBuildId up to toBuildId
+// The commits are read by pages, following the continuation token header from page to page
+func (r *AzureDevOpsRepository) GetBuildChanges(fromBuildId, toBuildId int) ([]model.BuildChanges, error) {
+	type BuildChanges model.PaginatedValue[model.BuildChanges]
+	changes := []model.BuildChanges{}
+	route := configureRoute(changesApiVersion, "build/changes?fromBuildId=%d&toBuildId=%d&$top=%d", fromBuildId, toBuildId, pageSize)
+	for {
+		var result BuildChanges
+		httpResponse, err := r.client.Get(route, nil)
+		if err != nil {
+			return []model.BuildChanges{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.BuildChanges{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+			return []model.BuildChanges{}, err
+		}
+		changes = append(changes, result.Value...)
+		token := httpResponse.Header.Get(continuationTokenHeader)
+		if token == "" {
+			return changes, nil
+		}
+		route = configureRoute(changesApiVersion, "build/changes?fromBuildId=%d&toBuildId=%d&$top=%d&continuationToken=%s", fromBuildId, toBuildId, pageSize, url.QueryEscape(token))
+	}
+}
+
 func (r *AzureDevOpsRepository) GetWorkItem(workItemId string) (*model.WorkItem, error) {
 	var buildWorkItems model.WorkItem
 	url := r.configureRouteWithVersion("wit/workItems/%s", workItemId)
@@ -138,9 +204,31 @@ func (r *AzureDevOpsRepository) GetWorkItem(workItemId string) (*model.WorkItem,
 	return &buildWorkItems, nil
 }
 
+func (r *AzureDevOpsRepository) GetWorkItemWithRelations(workItemId string) (*model.WorkItem, error) {
+	var workItem model.WorkItem
+	url := r.configureRouteWithVersion("wit/workItems/%s?$expand=relations", workItemId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	if err := readAndUnmarshal(httpResponse.Body, &workItem); err != nil {
+		return nil, err
+	}
+	return &workItem, nil
+}
+
 func (r *AzureDevOpsRepository) UpdateWorkitemField(workItemId string, operation model.OperationFields) error {
+	return r.UpdateWorkitemFields(workItemId, []model.OperationFields{operation})
+}
+
+func (r *AzureDevOpsRepository) UpdateWorkitemFields(workItemId string, operations []model.OperationFields) error {
 	url := r.configureRouteWithVersion("wit/workItems/%s", workItemId)
-	model, err := json.Marshal([]model.OperationFields{operation})
+	model, err := json.Marshal(operations)
 	if err != nil {
 		return err
 	}
@@ -155,6 +243,45 @@ func (r *AzureDevOpsRepository) UpdateWorkitemField(workItemId string, operation
 	return nil
 }
 
+// GetWorkItemComments returns every comment of the work item, following the continuation token from page to page
+func (r *AzureDevOpsRepository) GetWorkItemComments(workItemId string) ([]model.WorkItemComment, error) {
+	comments := []model.WorkItemComment{}
+	route := configureRoute(commentsApiVersion, "wit/workItems/%s/comments", workItemId)
+	for {
+		var result model.WorkItemComments
+		httpResponse, err := r.client.Get(route, nil)
+		if err != nil {
+			return []model.WorkItemComment{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.WorkItemComment{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+			return []model.WorkItemComment{}, err
+		}
+		comments = append(comments, result.Comments...)
+		if result.ContinuationToken == "" {
+			return comments, nil
+		}
+		route = configureRoute(commentsApiVersion, "wit/workItems/%s/comments?continuationToken=%s", workItemId, url.QueryEscape(result.ContinuationToken))
+	}
+}
+
+func (r *AzureDevOpsRepository) AddWorkItemComment(workItemId string, text string) error {
+	url := configureRoute(commentsApiVersion, "wit/workItems/%s/comments?format=markdown", workItemId)
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: text})
+	if err != nil {
+		return err
+	}
+	httpResponse, err := r.client.Post(url, body, nil)
+	if err != nil {
+		return err
+	}
+	return treatResult(httpResponse, http.StatusOK)
+}
+
 func (r *AzureDevOpsRepository) GetRepositoryById(uuid string) (*model.Repository, error) {
 	var result model.Repository
 	url := r.configureRouteWithVersion("git/repositories/%s", uuid)
@@ -171,13 +298,227 @@ func (r *AzureDevOpsRepository) GetRepositoryById(uuid string) (*model.Repositor
 	return &result, nil
 }
 
+func (r *AzureDevOpsRepository) GetPullRequest(pullRequestId int) (*model.PullRequest, error) {
+	var result model.PullRequest
+	url := r.configureRouteWithVersion("git/pullrequests/%d", pullRequestId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPullRequests returns the pull requests completed into targetRefName between minTime and maxTime
+// The pull requests are read by pages until a page isn't full
+func (r *AzureDevOpsRepository) GetPullRequests(repositoryId string, targetRefName string, minTime, maxTime time.Time) ([]model.PullRequest, error) {
+	type PullRequests model.PaginatedValue[model.PullRequest]
+	pullRequests := []model.PullRequest{}
+	for {
+		var result PullRequests
+		route := r.configureRouteWithVersion("git/repositories/%s/pullrequests?searchCriteria.status=completed&searchCriteria.targetRefName=%s&searchCriteria.queryTimeRangeType=closed&searchCriteria.minTime=%s&searchCriteria.maxTime=%s&$skip=%d&$top=%d",
+			repositoryId,
+			url.QueryEscape(targetRefName),
+			url.QueryEscape(minTime.UTC().Format(time.RFC3339)),
+			url.QueryEscape(maxTime.UTC().Format(time.RFC3339)),
+			len(pullRequests),
+			pageSize,
+		)
+		httpResponse, err := r.client.Get(route, nil)
+		if err != nil {
+			return []model.PullRequest{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.PullRequest{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+			return []model.PullRequest{}, err
+		}
+		pullRequests = append(pullRequests, result.Value...)
+		if len(result.Value) < pageSize {
+			return pullRequests, nil
+		}
+	}
+}
+
+// GetPullRequestWorkItems returns the references of the work items linked to a pull request
+func (r *AzureDevOpsRepository) GetPullRequestWorkItems(repositoryId string, pullRequestId int) ([]model.BuildWorkItems, error) {
+	type WorkItems model.PaginatedValue[model.BuildWorkItems]
+	var result WorkItems
+	url := r.configureRouteWithVersion("git/repositories/%s/pullRequests/%d/workitems", repositoryId, pullRequestId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return []model.BuildWorkItems{}, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return []model.BuildWorkItems{}, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return []model.BuildWorkItems{}, err
+	}
+	return result.Value, nil
+}
+
+// GetRunTimeline returns the stages, jobs and tasks of a run
+func (r *AzureDevOpsRepository) GetRunTimeline(runId int) (*model.Timeline, error) {
+	var result model.Timeline
+	url := r.configureRouteWithVersion("build/builds/%d/timeline", runId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *AzureDevOpsRepository) GetEnvironmentByName(name string) (*model.Environment, error) {
+	type Environments model.PaginatedValue[model.Environment]
+	var result Environments
+	route := configureRoute(environmentsApiVersion, "distributedtask/environments?name=%s", url.QueryEscape(name))
+	httpResponse, err := r.client.Get(route, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return nil, err
+	}
+	for _, environment := range result.Value {
+		if strings.EqualFold(environment.Name, name) {
+			return &environment, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: environment %s", ErrNotFound, name)
+}
+
+// GetEnvironmentDeploymentRecords returns the last deployments to an environment
+func (r *AzureDevOpsRepository) GetEnvironmentDeploymentRecords(environmentId int) ([]model.EnvironmentDeploymentRecord, error) {
+	type Records model.PaginatedValue[model.EnvironmentDeploymentRecord]
+	var result Records
+	url := configureRoute(environmentsApiVersion, "distributedtask/environments/%d/environmentdeploymentrecords?top=1000", environmentId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return []model.EnvironmentDeploymentRecord{}, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return []model.EnvironmentDeploymentRecord{}, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return []model.EnvironmentDeploymentRecord{}, err
+	}
+	return result.Value, nil
+}
+
+// GetMergeBases returns the best common ancestors of two commits
+func (r *AzureDevOpsRepository) GetMergeBases(repositoryId string, commitId string, otherCommitId string) ([]model.GitCommitRef, error) {
+	type Commits model.PaginatedValue[model.GitCommitRef]
+	var result Commits
+	url := r.configureRouteWithVersion("git/repositories/%s/commits/%s/mergebases?otherCommitId=%s", repositoryId, commitId, otherCommitId)
+	httpResponse, err := r.client.Get(url, nil)
+	if err != nil {
+		return []model.GitCommitRef{}, err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return []model.GitCommitRef{}, err
+	}
+	if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+		return []model.GitCommitRef{}, err
+	}
+	return result.Value, nil
+}
+
+// GetCommitsBetween returns the commits reachable from toCommitId but not from fromCommitId, with their work items
+// The commits are read by pages until a page isn't full
+func (r *AzureDevOpsRepository) GetCommitsBetween(repositoryId string, fromCommitId string, toCommitId string) ([]model.GitCommitRef, error) {
+	type Commits model.PaginatedValue[model.GitCommitRef]
+	type gitVersion struct {
+		Version     string `json:"version"`
+		VersionType string `json:"versionType"`
+	}
+	body, err := json.Marshal(struct {
+		ItemVersion      gitVersion `json:"itemVersion"`
+		CompareVersion   gitVersion `json:"compareVersion"`
+		IncludeWorkItems bool       `json:"includeWorkItems"`
+	}{
+		ItemVersion:      gitVersion{Version: toCommitId, VersionType: "commit"},
+		CompareVersion:   gitVersion{Version: fromCommitId, VersionType: "commit"},
+		IncludeWorkItems: true,
+	})
+	if err != nil {
+		return []model.GitCommitRef{}, err
+	}
+
+	commits := []model.GitCommitRef{}
+	for {
+		var result Commits
+		url := r.configureRouteWithVersion("git/repositories/%s/commitsbatch?$skip=%d&$top=%d", repositoryId, len(commits), pageSize)
+		httpResponse, err := r.client.Post(url, body, nil)
+		if err != nil {
+			return []model.GitCommitRef{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.GitCommitRef{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+			return []model.GitCommitRef{}, err
+		}
+		commits = append(commits, result.Value...)
+		if len(result.Value) < pageSize {
+			return commits, nil
+		}
+	}
+}
+
+// GetCommitChanges returns the files changed by a commit
+// The changes are read by pages until a page isn't full
+func (r *AzureDevOpsRepository) GetCommitChanges(repositoryId string, commitId string) ([]model.GitChange, error) {
+	type Changes struct {
+		Changes []model.GitChange `json:"changes"`
+	}
+	changes := []model.GitChange{}
+	for {
+		var result Changes
+		url := r.configureRouteWithVersion("git/repositories/%s/commits/%s/changes?skip=%d&top=%d", repositoryId, commitId, len(changes), pageSize)
+		httpResponse, err := r.client.Get(url, nil)
+		if err != nil {
+			return []model.GitChange{}, err
+		}
+		if err := treatResult(httpResponse, http.StatusOK); err != nil {
+			return []model.GitChange{}, err
+		}
+		if err := readAndUnmarshal(httpResponse.Body, &result); err != nil {
+			return []model.GitChange{}, err
+		}
+		changes = append(changes, result.Changes...)
+		if len(result.Changes) < pageSize {
+			return changes, nil
+		}
+	}
+}
+
 func (r *AzureDevOpsRepository) configureRouteWithVersion(route string, values ...any) string {
+	return configureRoute(r.version, route, values...)
+}
+
+func configureRoute(version string, route string, values ...any) string {
 	url := fmt.Sprintf(route, values...)
 	if strings.Contains(url, "?") {
-		url = fmt.Sprintf("_apis/%s&api-version=%s", url, r.version)
+		url = fmt.Sprintf("_apis/%s&api-version=%s", url, version)
 
 	} else {
-		url = fmt.Sprintf("_apis/%s?api-version=%s", url, r.version)
+		url = fmt.Sprintf("_apis/%s?api-version=%s", url, version)
 	}
 	return url
 }
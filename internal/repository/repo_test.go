@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Damien-Venant/prev-updater/internal/model"
 	"github.com/stretchr/testify/assert"
@@ -100,6 +103,30 @@ func TestGetPipelineRuns(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestGetBuildsByIds(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	ids := make([]int, 0, 201)
+	for id := 1; id <= 201; id++ {
+		ids = append(ids, id)
+	}
+	firstIds := make([]string, 0, 200)
+	for id := 1; id <= 200; id++ {
+		firstIds = append(firstIds, strconv.Itoa(id))
+	}
+	firstBatch := model.PaginatedValue[model.Build]{Count: 1, Value: []model.Build{{Id: 42, Reason: "individualCI"}}}
+	lastBatch := model.PaginatedValue[model.Build]{Count: 1, Value: []model.Build{{Id: 201, Reason: "schedule"}}}
+	mockClient.On("Get", "_apis/build/builds?buildIds="+strings.Join(firstIds, ",")+"&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, firstBatch), nil)
+	mockClient.On("Get", "_apis/build/builds?buildIds=201&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, lastBatch), nil)
+
+	builds, err := repo.GetBuildsByIds(ids)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Build{{Id: 42, Reason: "individualCI"}, {Id: 201, Reason: "schedule"}}, builds)
+	mockClient.AssertExpectations(t)
+}
+
 func TestGetBuildWorkItem(t *testing.T) {
 	mockClient := new(MockHttpClient)
 	repo := NewAdoRepository(mockClient)
@@ -127,6 +154,243 @@ func TestGetBuildWorkItem(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestGetBuildChanges(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.BuildChanges]{
+		Count: 1,
+		Value: []model.BuildChanges{{Id: "abc123", Message: "Fix login", Author: model.IdentityRef{DisplayName: "Dev"}}},
+	}
+
+	mockClient.On("Get", "_apis/build/changes?fromBuildId=100&toBuildId=200&$top=1000&api-version=7.1-preview.2", mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	changes, err := repo.GetBuildChanges(100, 200)
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, changes)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetBuildChanges_ShouldFollowContinuationToken(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	firstPage := makeHttpResponse(200, model.PaginatedValue[model.BuildChanges]{Count: 1, Value: []model.BuildChanges{{Id: "abc123"}}})
+	firstPage.Header.Set(continuationTokenHeader, "next page")
+	lastPage := makeHttpResponse(200, model.PaginatedValue[model.BuildChanges]{Count: 1, Value: []model.BuildChanges{{Id: "def456"}}})
+	mockClient.On("Get", "_apis/build/changes?fromBuildId=100&toBuildId=200&$top=1000&api-version=7.1-preview.2", mock.Anything).Return(firstPage, nil)
+	mockClient.On("Get", "_apis/build/changes?fromBuildId=100&toBuildId=200&$top=1000&continuationToken=next+page&api-version=7.1-preview.2", mock.Anything).Return(lastPage, nil)
+
+	changes, err := repo.GetBuildChanges(100, 200)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.BuildChanges{{Id: "abc123"}, {Id: "def456"}}, changes)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetPullRequest(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	expected := model.PullRequest{PullRequestId: 7, SourceRefName: "refs/heads/feature/3-logout"}
+	mockClient.On("Get", "_apis/git/pullrequests/7?api-version=7.1", mock.Anything).Return(makeHttpResponse(200, expected), nil)
+
+	pullRequest, err := repo.GetPullRequest(7)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, *pullRequest)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetPullRequests(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.PullRequest]{Count: 1, Value: []model.PullRequest{{PullRequestId: 7}}}
+	expectedUrl := "_apis/git/repositories/repo-id/pullrequests?searchCriteria.status=completed&searchCriteria.targetRefName=refs%2Fheads%2Fmain" +
+		"&searchCriteria.queryTimeRangeType=closed&searchCriteria.minTime=2025-04-12T10%3A00%3A00Z&searchCriteria.maxTime=2025-04-13T10%3A00%3A00Z" +
+		"&$skip=0&$top=1000&api-version=7.1"
+	mockClient.On("Get", expectedUrl, mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	pullRequests, err := repo.GetPullRequests("repo-id", "refs/heads/main",
+		time.Date(2025, 4, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 13, 10, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, pullRequests)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetPullRequests_ShouldReadEveryPage(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	firstPage := model.PaginatedValue[model.PullRequest]{Count: pageSize, Value: make([]model.PullRequest, pageSize)}
+	lastPage := model.PaginatedValue[model.PullRequest]{Count: 1, Value: []model.PullRequest{{PullRequestId: 7}}}
+	route := "_apis/git/repositories/repo-id/pullrequests?searchCriteria.status=completed&searchCriteria.targetRefName=refs%2Fheads%2Fmain" +
+		"&searchCriteria.queryTimeRangeType=closed&searchCriteria.minTime=2025-04-12T10%3A00%3A00Z&searchCriteria.maxTime=2025-04-13T10%3A00%3A00Z"
+	mockClient.On("Get", route+"&$skip=0&$top=1000&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, firstPage), nil)
+	mockClient.On("Get", route+"&$skip=1000&$top=1000&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, lastPage), nil)
+
+	pullRequests, err := repo.GetPullRequests("repo-id", "refs/heads/main",
+		time.Date(2025, 4, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 13, 10, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err)
+	assert.Len(t, pullRequests, pageSize+1)
+	assert.Equal(t, 7, pullRequests[pageSize].PullRequestId)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetPullRequestWorkItems(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.BuildWorkItems]{Count: 1, Value: []model.BuildWorkItems{{Id: "1"}}}
+	mockClient.On("Get", "_apis/git/repositories/repo-id/pullRequests/7/workitems?api-version=7.1", mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	workItems, err := repo.GetPullRequestWorkItems("repo-id", 7)
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, workItems)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetRunTimeline(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	expected := model.Timeline{Records: []model.TimelineRecord{{Type: "Stage", Name: "QA", Result: "succeeded"}}}
+	mockClient.On("Get", "_apis/build/builds/42/timeline?api-version=7.1", mock.Anything).Return(makeHttpResponse(200, expected), nil)
+
+	timeline, err := repo.GetRunTimeline(42)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, *timeline)
+}
+
+func TestGetEnvironmentByName(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.Environment]{Count: 1, Value: []model.Environment{{Id: 5, Name: "QA"}}}
+	mockClient.On("Get", "_apis/distributedtask/environments?name=qa&api-version=7.1-preview.1", mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	environment, err := repo.GetEnvironmentByName("qa")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 5, environment.Id)
+}
+
+func TestGetEnvironmentByName_NotFound(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.Environment]{Count: 0, Value: []model.Environment{}}
+	mockClient.On("Get", mock.Anything, mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	environment, err := repo.GetEnvironmentByName("Prod")
+
+	assert.Nil(t, environment)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetEnvironmentDeploymentRecords(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.EnvironmentDeploymentRecord]{Count: 1, Value: []model.EnvironmentDeploymentRecord{{Id: 1, StageName: "deploy_qa"}}}
+	mockClient.On("Get", "_apis/distributedtask/environments/5/environmentdeploymentrecords?top=1000&api-version=7.1-preview.1", mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	records, err := repo.GetEnvironmentDeploymentRecords(5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, records)
+}
+
+func TestGetMergeBases(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.GitCommitRef]{Count: 1, Value: []model.GitCommitRef{{CommitId: "abc123"}}}
+	mockClient.On("Get", "_apis/git/repositories/repo-id/commits/def456/mergebases?otherCommitId=ghi789&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	commits, err := repo.GetMergeBases("repo-id", "def456", "ghi789")
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, commits)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetCommitsBetween(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	paginated := model.PaginatedValue[model.GitCommitRef]{Count: 1, Value: []model.GitCommitRef{
+		{CommitId: "def456", Comment: "Fix login", WorkItems: []model.ResourceRef{{Id: "42", Url: "https://dev.azure.com/org/_apis/wit/workItems/42"}}},
+	}}
+	expectedBody := `{"itemVersion":{"version":"def456","versionType":"commit"},"compareVersion":{"version":"abc123","versionType":"commit"},"includeWorkItems":true}`
+	mockClient.On("Post", "_apis/git/repositories/repo-id/commitsbatch?$skip=0&$top=1000&api-version=7.1", []byte(expectedBody), mock.Anything).Return(makeHttpResponse(200, paginated), nil)
+
+	commits, err := repo.GetCommitsBetween("repo-id", "abc123", "def456")
+
+	assert.Nil(t, err)
+	assert.Equal(t, paginated.Value, commits)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetCommitsBetween_ShouldReadEveryPage(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	firstPage := model.PaginatedValue[model.GitCommitRef]{Count: pageSize, Value: make([]model.GitCommitRef, pageSize)}
+	lastPage := model.PaginatedValue[model.GitCommitRef]{Count: 1, Value: []model.GitCommitRef{{CommitId: "abc123"}}}
+	mockClient.On("Post", "_apis/git/repositories/repo-id/commitsbatch?$skip=0&$top=1000&api-version=7.1", mock.Anything, mock.Anything).Return(makeHttpResponse(200, firstPage), nil)
+	mockClient.On("Post", "_apis/git/repositories/repo-id/commitsbatch?$skip=1000&$top=1000&api-version=7.1", mock.Anything, mock.Anything).Return(makeHttpResponse(200, lastPage), nil)
+
+	commits, err := repo.GetCommitsBetween("repo-id", "abc123", "def456")
+
+	assert.Nil(t, err)
+	assert.Len(t, commits, pageSize+1)
+	assert.Equal(t, "abc123", commits[pageSize].CommitId)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetCommitChanges(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	response := map[string][]model.GitChange{"changes": {
+		{ChangeType: "edit", Item: model.GitItem{Path: "/services/api/main.go"}},
+		{ChangeType: "add", Item: model.GitItem{Path: "/docs/README.md"}},
+	}}
+	mockClient.On("Get", "_apis/git/repositories/repo-id/commits/def456/changes?skip=0&top=1000&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, response), nil)
+
+	changes, err := repo.GetCommitChanges("repo-id", "def456")
+
+	assert.Nil(t, err)
+	assert.Equal(t, response["changes"], changes)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetCommitChanges_ShouldReadEveryPage(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	firstPage := map[string][]model.GitChange{"changes": make([]model.GitChange, pageSize)}
+	lastPage := map[string][]model.GitChange{"changes": {{ChangeType: "rename", Item: model.GitItem{Path: "/services/api/main.go"}}}}
+	mockClient.On("Get", "_apis/git/repositories/repo-id/commits/def456/changes?skip=0&top=1000&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, firstPage), nil)
+	mockClient.On("Get", "_apis/git/repositories/repo-id/commits/def456/changes?skip=1000&top=1000&api-version=7.1", mock.Anything).Return(makeHttpResponse(200, lastPage), nil)
+
+	changes, err := repo.GetCommitChanges("repo-id", "def456")
+
+	assert.Nil(t, err)
+	assert.Len(t, changes, pageSize+1)
+	assert.Equal(t, "/services/api/main.go", changes[pageSize].Item.Path)
+	mockClient.AssertExpectations(t)
+}
+
 func TestGetWorkItem(t *testing.T) {
 	mockClient := new(MockHttpClient)
 	repo := NewAdoRepository(mockClient)
@@ -381,3 +645,84 @@ func TestTreatResult_When_StatusCode_Does_Not_Match_ExpectedStatusCode(t *testin
 		})
 	}
 }
+
+func TestGetWorkItemWithRelations(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	expectedItem := model.WorkItem{Id: 42, Relations: []model.WorkItemRelation{{Rel: "System.LinkTypes.Hierarchy-Reverse", Url: "url/10"}}}
+
+	mockResp := makeHttpResponse(200, expectedItem)
+	mockClient.On("Get", "_apis/wit/workItems/42?$expand=relations&api-version=7.1", mock.Anything).Return(mockResp, nil)
+
+	item, err := repo.GetWorkItemWithRelations("42")
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedItem, *item)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdateWorkitemFields(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	mockResp := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+	}
+	expectedBody := `[{"op":"add","path":"/fields/Custom.Version","value":"25.4.13"},{"op":"add","path":"/fields/Custom.RunId","value":42}]`
+
+	mockClient.On("Patch", "_apis/wit/workItems/42?api-version=7.1", []byte(expectedBody), mock.Anything).Return(mockResp, nil)
+
+	err := repo.UpdateWorkitemFields("42", []model.OperationFields{
+		{Op: "add", Path: "/fields/Custom.Version", Value: "25.4.13"},
+		{Op: "add", Path: "/fields/Custom.RunId", Value: 42},
+	})
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetWorkItemComments(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	expected := model.WorkItemComments{TotalCount: 1, Comments: []model.WorkItemComment{{Id: 1, Text: "comment"}}}
+	mockResp := makeHttpResponse(200, expected)
+	mockClient.On("Get", "_apis/wit/workItems/42/comments?api-version=7.1-preview.4", mock.Anything).Return(mockResp, nil)
+
+	comments, err := repo.GetWorkItemComments("42")
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Comments, comments)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetWorkItemComments_ShouldReadEveryPage(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	firstPage := model.WorkItemComments{TotalCount: 3, ContinuationToken: "page+2", Comments: []model.WorkItemComment{{Id: 1}, {Id: 2}}}
+	lastPage := model.WorkItemComments{TotalCount: 3, Comments: []model.WorkItemComment{{Id: 3, Text: "<!-- prev-updater:25.4.13 -->"}}}
+	mockClient.On("Get", "_apis/wit/workItems/42/comments?api-version=7.1-preview.4", mock.Anything).Return(makeHttpResponse(200, firstPage), nil)
+	mockClient.On("Get", "_apis/wit/workItems/42/comments?continuationToken=page%2B2&api-version=7.1-preview.4", mock.Anything).Return(makeHttpResponse(200, lastPage), nil)
+
+	comments, err := repo.GetWorkItemComments("42")
+
+	assert.Nil(t, err)
+	assert.Equal(t, append(firstPage.Comments, lastPage.Comments...), comments)
+	mockClient.AssertExpectations(t)
+}
+
+func TestAddWorkItemComment(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewAdoRepository(mockClient)
+
+	mockResp := makeHttpResponse(200, model.WorkItemComment{Id: 1})
+	mockClient.On("Post", "_apis/wit/workItems/42/comments?format=markdown&api-version=7.1-preview.4", []byte(`{"text":"comment"}`), mock.Anything).Return(mockResp, nil)
+
+	err := repo.AddWorkItemComment("42", "comment")
+
+	assert.Nil(t, err)
+	mockClient.AssertExpectations(t)
+}
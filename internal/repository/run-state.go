@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	runStateDirectoryName string = "state"
+	runStateExtension     string = ".json"
+)
+
+var (
+	ErrInvalidRunStateKey error = errors.New("invalid run state key")
+)
+
+// RunStateRepository stores the checkpoints of the catch-up mode as JSON files, one per key, in the state directory
+type RunStateRepository struct {
+	directory string
+}
+
+func NewRunStateRepository(configDirectory string) (*RunStateRepository, error) {
+	directory := path.Join(configDirectory, runStateDirectoryName)
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, err
+	}
+	return &RunStateRepository{
+		directory: directory,
+	}, nil
+}
+
+// Load returns the checkpoint of key, or nil when there is none yet
+func (repo *RunStateRepository) Load(key string) (*model.RunCheckpoint, error) {
+	fileName, err := repo.fileName(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var checkpoint model.RunCheckpoint
+	if err := json.Unmarshal(content, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint in a temporary file then renames it, so a checkpoint is never half written
+func (repo *RunStateRepository) Save(checkpoint model.RunCheckpoint) error {
+	fileName, err := repo.fileName(checkpoint.Key)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := fileName + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, fileName)
+}
+
+func (repo *RunStateRepository) fileName(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", ErrInvalidRunStateKey
+	}
+	return path.Join(repo.directory, key+runStateExtension), nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunStateRepository_SaveLoad(t *testing.T) {
+	repo, err := NewRunStateRepository(t.TempDir())
+	assert.Nil(t, err)
+
+	checkpoint, err := repo.Load("862-refs%2Fheads%2Fmain")
+	assert.Nil(t, err)
+	assert.Nil(t, checkpoint)
+
+	expected := model.RunCheckpoint{
+		Key:         "862-refs%2Fheads%2Fmain",
+		PipelineId:  862,
+		RefName:     "refs/heads/main",
+		LastRunId:   42,
+		LastRunName: "25.4.13",
+		UpdatedAt:   time.Unix(10, 0).UTC(),
+	}
+	assert.Nil(t, repo.Save(expected))
+	expected.LastRunId = 43
+	assert.Nil(t, repo.Save(expected))
+
+	checkpoint, err = repo.Load(expected.Key)
+	assert.Nil(t, err)
+	assert.Equal(t, &expected, checkpoint)
+}
+
+func TestRunStateRepository_ShouldRejectInvalidKey(t *testing.T) {
+	repo, _ := NewRunStateRepository(t.TempDir())
+
+	assert.ErrorIs(t, repo.Save(model.RunCheckpoint{Key: "../config"}), ErrInvalidRunStateKey)
+	_, err := repo.Load("")
+	assert.ErrorIs(t, err, ErrInvalidRunStateKey)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	httpclient "github.com/Damien-Venant/prev-updater/pkg/http-client"
+)
+
+// SlackRepository posts the release event to a Slack incoming webhook
+type SlackRepository struct {
+	client httpclient.HttpClientInterface
+}
+
+const (
+	// slackSectionMaxLength is the longest text Slack accepts in a section block
+	slackSectionMaxLength int = 3000
+	// slackMaxBlocks is the number of blocks Slack accepts in a message
+	slackMaxBlocks int = 50
+)
+
+type (
+	slackMessage struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}
+
+	slackBlock struct {
+		Type string    `json:"type"`
+		Text slackText `json:"text"`
+	}
+
+	slackText struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+)
+
+func NewSlackRepository(client httpclient.HttpClientInterface) *SlackRepository {
+	return &SlackRepository{
+		client: client,
+	}
+}
+
+func (repo *SlackRepository) Name() string {
+	return "slack"
+}
+
+func (repo *SlackRepository) Notify(data model.N8nResult) error {
+	body, err := json.Marshal(newSlackMessage(data))
+	if err != nil {
+		return err
+	}
+
+	httpResponse, err := repo.client.Post("", body, nil)
+	if err != nil {
+		return err
+	}
+	return treatSuccessResult(httpResponse)
+}
+
+// slackEscaper escapes the control characters of Slack mrkdwn, as required by Slack for user text
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func newSlackMessage(data model.N8nResult) slackMessage {
+	title := slackEscaper.Replace(releaseTitle(data))
+	lines := make([]string, 0, len(data.WorkItems))
+	for _, workItem := range data.WorkItems {
+		lines = append(lines, fmt.Sprintf("• #%d %s", workItem.Id, slackEscaper.Replace(workItemLabel(workItem))))
+	}
+
+	blocks := []slackBlock{
+		{Type: "section", Text: slackText{Type: "mrkdwn", Text: "*" + title + "*"}},
+	}
+	for _, section := range slackSections(lines, slackMaxBlocks-len(blocks)) {
+		blocks = append(blocks, slackBlock{Type: "section", Text: slackText{Type: "mrkdwn", Text: section}})
+	}
+	return slackMessage{
+		Text:   title,
+		Blocks: blocks,
+	}
+}
+
+// slackSections splits the lines into at most maxSections texts that fit in a section block
+// A line too long for a section is cut, the lines that don't fit in the sections are replaced by a last "and N more" line
+func slackSections(lines []string, maxSections int) []string {
+	lines = slices.Clone(lines)
+	for index := range lines {
+		lines[index] = truncateText(lines[index], slackSectionMaxLength)
+	}
+
+	sections := []string{}
+	section := ""
+	for index, line := range lines {
+		if section != "" && len(section)+len("\n")+len(line) <= slackSectionMaxLength {
+			section += "\n" + line
+			continue
+		}
+		if section != "" {
+			sections = append(sections, section)
+		}
+		if len(sections) == maxSections-1 && !fitsInSection(lines[index:]) {
+			return append(sections, moreLines(lines[index:]))
+		}
+		section = line
+	}
+	if section != "" {
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// fitsInSection tells if the lines fit together in one section
+func fitsInSection(lines []string) bool {
+	return len(strings.Join(lines, "\n")) <= slackSectionMaxLength
+}
+
+// moreLines keeps the first lines fitting in a section with the count of the others
+func moreLines(lines []string) string {
+	section := ""
+	for index, line := range lines {
+		next := line
+		if section != "" {
+			next = section + "\n" + line
+		}
+		rest := len(lines) - index - 1
+		if rest > 0 && len(next)+len(moreLine(rest)) > slackSectionMaxLength || len(next) > slackSectionMaxLength {
+			return strings.TrimPrefix(section+moreLine(len(lines)-index), "\n")
+		}
+		section = next
+	}
+	return section
+}
+
+func moreLine(count int) string {
+	return fmt.Sprintf("\n…and %d more", count)
+}
+
+// truncateText cuts the text to at most maxLength bytes, without splitting a character, and ends it with an ellipsis when it is cut
+func truncateText(text string, maxLength int) string {
+	if len(text) <= maxLength {
+		return text
+	}
+	const ellipsis = "…"
+	cut := maxLength - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + ellipsis
+}
+
+// workItemLabel is the title of the work item followed by the repositories it came from, if any
+func workItemLabel(workItem model.N8NWorkItems) string {
+	if len(workItem.Repositories) == 0 {
+		return workItem.Title
+	}
+	return fmt.Sprintf("%s (%s)", workItem.Title, strings.Join(workItem.Repositories, ", "))
+}
+
+// releaseTitle is the one line summary of a release event shared by the chat sinks
+func releaseTitle(data model.N8nResult) string {
+	title := fmt.Sprintf("Version %s: %d work item(s)", data.Version, len(data.WorkItems))
+	if data.SourceBranch != "" {
+		title = fmt.Sprintf("Version %s on %s: %d work item(s)", data.Version, data.SourceBranch, len(data.WorkItems))
+	}
+	return title
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	httpclient "github.com/Damien-Venant/prev-updater/pkg/http-client"
+)
+
+const (
+	GitHubApiUrl    string = "https://api.github.com"
+	BitbucketApiUrl string = "https://api.bitbucket.org/2.0"
+
+	headsPrefix string = "refs/heads/"
+)
+
+// GitHubRepository reads the repositories of the pipelines built from GitHub through a service connection
+type GitHubRepository struct {
+	client httpclient.HttpClientInterface
+}
+
+// BitbucketRepository reads the repositories of the pipelines built from Bitbucket Cloud
+type BitbucketRepository struct {
+	client httpclient.HttpClientInterface
+}
+
+func NewGitHubRepository(client httpclient.HttpClientInterface) *GitHubRepository {
+	return &GitHubRepository{
+		client: client,
+	}
+}
+
+func NewBitbucketRepository(client httpclient.HttpClientInterface) *BitbucketRepository {
+	return &BitbucketRepository{
+		client: client,
+	}
+}
+
+// DefaultBranch returns the ref of the default branch of the repository, named owner/repo
+func (repo *GitHubRepository) DefaultBranch(repository model.RunRepository) (string, error) {
+	var result struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := getJson(repo.client, fmt.Sprintf("repos/%s", repository.FullName), &result); err != nil {
+		return "", err
+	}
+	return headsPrefix + result.DefaultBranch, nil
+}
+
+// DefaultBranch returns the ref of the main branch of the repository, named workspace/repo
+func (repo *BitbucketRepository) DefaultBranch(repository model.RunRepository) (string, error) {
+	var result struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := getJson(repo.client, fmt.Sprintf("repositories/%s", repository.FullName), &result); err != nil {
+		return "", err
+	}
+	return headsPrefix + result.MainBranch.Name, nil
+}
+
+func getJson[T any](client httpclient.HttpClientInterface, route string, result *T) error {
+	httpResponse, err := client.Get(route, nil)
+	if err != nil {
+		return err
+	}
+	if err := treatResult(httpResponse, http.StatusOK); err != nil {
+		return err
+	}
+	return readAndUnmarshal(httpResponse.Body, result)
+}
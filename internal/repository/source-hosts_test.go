@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHubDefaultBranch(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewGitHubRepository(mockClient)
+
+	mockClient.On("Get", "repos/org/product", http.Header(nil)).Return(makeHttpResponse(http.StatusOK, map[string]interface{}{"default_branch": "develop"}), nil)
+
+	branch, err := repo.DefaultBranch(model.RunRepository{Type: "gitHub", FullName: "org/product"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "refs/heads/develop", branch)
+}
+
+func TestBitbucketDefaultBranch(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewBitbucketRepository(mockClient)
+
+	mockClient.On("Get", "repositories/team/product", http.Header(nil)).Return(makeHttpResponse(http.StatusOK, map[string]interface{}{"mainbranch": map[string]string{"name": "master"}}), nil)
+
+	branch, err := repo.DefaultBranch(model.RunRepository{Type: "bitbucket", FullName: "team/product"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "refs/heads/master", branch)
+}
+
+func TestGitHubDefaultBranch_NotFound(t *testing.T) {
+	mockClient := new(MockHttpClient)
+	repo := NewGitHubRepository(mockClient)
+
+	mockClient.On("Get", "repos/org/private", http.Header(nil)).Return(makeHttpResponse(http.StatusNotFound, nil), nil)
+
+	_, err := repo.DefaultBranch(model.RunRepository{Type: "gitHub", FullName: "org/private"})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
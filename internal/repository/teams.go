@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	httpclient "github.com/Damien-Venant/prev-updater/pkg/http-client"
+)
+
+const (
+	adaptiveCardContentType string = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema      string = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     string = "1.4"
+)
+
+// TeamsRepository posts the release event as an Adaptive Card to a Microsoft Teams webhook
+type TeamsRepository struct {
+	client httpclient.HttpClientInterface
+}
+
+type (
+	teamsMessage struct {
+		Type        string            `json:"type"`
+		Attachments []teamsAttachment `json:"attachments"`
+	}
+
+	teamsAttachment struct {
+		ContentType string       `json:"contentType"`
+		Content     adaptiveCard `json:"content"`
+	}
+
+	adaptiveCard struct {
+		Schema  string                `json:"$schema"`
+		Type    string                `json:"type"`
+		Version string                `json:"version"`
+		Body    []adaptiveCardElement `json:"body"`
+	}
+
+	adaptiveCardElement struct {
+		Type   string             `json:"type"`
+		Text   string             `json:"text,omitempty"`
+		Weight string             `json:"weight,omitempty"`
+		Size   string             `json:"size,omitempty"`
+		Wrap   bool               `json:"wrap,omitempty"`
+		Facts  []adaptiveCardFact `json:"facts,omitempty"`
+	}
+
+	adaptiveCardFact struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+	}
+)
+
+func NewTeamsRepository(client httpclient.HttpClientInterface) *TeamsRepository {
+	return &TeamsRepository{
+		client: client,
+	}
+}
+
+func (repo *TeamsRepository) Name() string {
+	return "teams"
+}
+
+func (repo *TeamsRepository) Notify(data model.N8nResult) error {
+	body, err := json.Marshal(newTeamsMessage(data))
+	if err != nil {
+		return err
+	}
+
+	httpResponse, err := repo.client.Post("", body, nil)
+	if err != nil {
+		return err
+	}
+	return treatSuccessResult(httpResponse)
+}
+
+func newTeamsMessage(data model.N8nResult) teamsMessage {
+	facts := make([]adaptiveCardFact, 0, len(data.WorkItems))
+	for _, workItem := range data.WorkItems {
+		facts = append(facts, adaptiveCardFact{Title: fmt.Sprintf("#%d", workItem.Id), Value: workItemLabel(workItem)})
+	}
+
+	body := []adaptiveCardElement{
+		{Type: "TextBlock", Text: releaseTitle(data), Weight: "Bolder", Size: "Medium", Wrap: true},
+	}
+	if len(facts) > 0 {
+		body = append(body, adaptiveCardElement{Type: "FactSet", Facts: facts})
+	}
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: adaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+				},
+			},
+		},
+	}
+}
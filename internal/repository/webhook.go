@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	httpclient "github.com/Damien-Venant/prev-updater/pkg/http-client"
+)
+
+// WebhookRepository posts the release event as is to a generic JSON webhook
+type WebhookRepository struct {
+	client  httpclient.HttpClientInterface
+	encoder PayloadEncoder
+}
+
+// NewWebhookRepository returns a webhook sink, the event is sent as plain JSON when encoder is nil
+func NewWebhookRepository(client httpclient.HttpClientInterface, encoder PayloadEncoder) *WebhookRepository {
+	if encoder == nil {
+		encoder = JsonEncoder{}
+	}
+	return &WebhookRepository{
+		client:  client,
+		encoder: encoder,
+	}
+}
+
+func (repo *WebhookRepository) Name() string {
+	return "webhook"
+}
+
+func (repo *WebhookRepository) Notify(data model.N8nResult) error {
+	body, headers, err := repo.encoder.Encode(data)
+	if err != nil {
+		return err
+	}
+
+	httpResponse, err := repo.client.Post("", body, headers)
+	if err != nil {
+		return err
+	}
+	return treatSuccessResult(httpResponse)
+}
@@ -0,0 +1,215 @@
+package usescases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	BaselineSameRef       string = "same-ref"
+	BaselineDefaultBranch string = "default-branch"
+	BaselineLowerVersion  string = "lower-version"
+	BaselineMergeBase     string = "merge-base"
+	BaselineTag           string = "tag"
+)
+
+type (
+	// BaselineStrategy finds the run the last run is compared with, among the runs older than it
+	// olderRuns are sorted from the newest to the oldest
+	BaselineStrategy interface {
+		Name() string
+		Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error)
+	}
+
+	// sameRefBaseline is the previous run on the ref of the run
+	sameRefBaseline struct{}
+
+	// defaultBranchBaseline is the last run on the default branch of the repository
+	// The default branch is read once, unless it is set by the option
+	defaultBranchBaseline struct {
+		usesCases     *AdoUsesCases
+		repositoryId  string
+		defaultBranch string
+	}
+
+	// lowerVersionBaseline is the last run whose version is lower than the version of the run
+	lowerVersionBaseline struct{}
+
+	// mergeBaseBaseline is the run of the merge-base between the run and the last run on the default branch
+	mergeBaseBaseline struct {
+		defaultBranch *defaultBranchBaseline
+	}
+
+	// tagBaseline is the last run carrying a build tag, e.g. release
+	// The tags are those of the builds read by filterRuns, so no request is made per run
+	tagBaseline struct {
+		tag string
+	}
+)
+
+// baselineStrategies returns the strategies of param, tried in order until one finds a baseline
+// It returns no strategy when param has neither strategy nor default branch, getRunsToUpdate then uses its default ones
+func (u *AdoUsesCases) baselineStrategies(param UpdateFieldsParams) ([]BaselineStrategy, error) {
+	names := param.Baselines
+	if len(names) == 0 && param.DefaultBranch != "" {
+		names = []string{BaselineSameRef, BaselineDefaultBranch}
+	}
+	strategies := make([]BaselineStrategy, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case BaselineSameRef:
+			strategies = append(strategies, sameRefBaseline{})
+		case BaselineDefaultBranch:
+			strategies = append(strategies, u.newDefaultBranchBaseline(param.RepositoryId, param.DefaultBranch))
+		case BaselineLowerVersion:
+			strategies = append(strategies, lowerVersionBaseline{})
+		case BaselineMergeBase:
+			strategies = append(strategies, &mergeBaseBaseline{defaultBranch: u.newDefaultBranchBaseline(param.RepositoryId, param.DefaultBranch)})
+		case BaselineTag:
+			if param.BaselineTag == "" {
+				return nil, fmt.Errorf("%w: the tag strategy needs a build tag", ErrInvalidBaseline)
+			}
+			strategies = append(strategies, &tagBaseline{tag: param.BaselineTag})
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBaseline, name)
+		}
+	}
+	return strategies, nil
+}
+
+// defaultBaselineStrategies returns the previous run on the same ref then the last run on the default branch
+// The default branch is read first, so an unknown repository fails before any baseline is looked for
+// A repository without source host is only an error when its default branch is needed
+func (u *AdoUsesCases) defaultBaselineStrategies(repositoryId string, run model.PipelineRuns) ([]BaselineStrategy, error) {
+	defaultBranch := u.newDefaultBranchBaseline(repositoryId, "")
+	if _, err := defaultBranch.getDefaultBranch(run); err != nil && !errors.Is(err, ErrUnknownDefaultBranch) {
+		return nil, err
+	}
+	return []BaselineStrategy{sameRefBaseline{}, defaultBranch}, nil
+}
+
+func (u *AdoUsesCases) newDefaultBranchBaseline(repositoryId string, defaultBranch string) *defaultBranchBaseline {
+	if defaultBranch != "" {
+		defaultBranch = normalizeRef(defaultBranch)
+	}
+	return &defaultBranchBaseline{usesCases: u, repositoryId: repositoryId, defaultBranch: defaultBranch}
+}
+
+// findBaseline returns the baseline of the first strategy that finds one, or the errors of every strategy
+func findBaseline(strategies []BaselineStrategy, run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	var errMap error = nil
+	for _, strategy := range strategies {
+		baseline, err := strategy.Baseline(run, olderRuns)
+		if err == nil {
+			return baseline, nil
+		}
+		errMap = errors.Join(errMap, fmt.Errorf("%s: %w", strategy.Name(), err))
+	}
+	return nil, errMap
+}
+
+func (sameRefBaseline) Name() string {
+	return BaselineSameRef
+}
+
+func (sameRefBaseline) Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	refName := runRefName(run)
+	return firstRun(olderRuns, fmt.Errorf("%w: no older run on %s", ErrNoBaseline, refName), func(pre model.PipelineRuns) bool {
+		return runRefName(pre) == refName
+	})
+}
+
+func (b *defaultBranchBaseline) Name() string {
+	return BaselineDefaultBranch
+}
+
+func (b *defaultBranchBaseline) Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	defaultBranch, err := b.getDefaultBranch(run)
+	if err != nil {
+		return nil, err
+	}
+	return firstRun(olderRuns, fmt.Errorf("%w: no older run on the default branch %s", ErrNoBaseline, defaultBranch), func(pre model.PipelineRuns) bool {
+		return runRefName(pre) == defaultBranch
+	})
+}
+
+func (b *defaultBranchBaseline) getDefaultBranch(run model.PipelineRuns) (string, error) {
+	if b.defaultBranch != "" {
+		return b.defaultBranch, nil
+	}
+	defaultBranch, err := b.usesCases.getDefaultBranch(b.repositoryId, run)
+	if err != nil {
+		return "", err
+	}
+	b.defaultBranch = defaultBranch
+	return defaultBranch, nil
+}
+
+func (lowerVersionBaseline) Name() string {
+	return BaselineLowerVersion
+}
+
+func (lowerVersionBaseline) Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	version := newVersion(run.Name)
+	return firstRun(olderRuns, fmt.Errorf("%w: no run with a version lower than %s", ErrNoBaseline, run.Name), func(pre model.PipelineRuns) bool {
+		return newVersion(pre.Name).isSmallerThan(version) == 1
+	})
+}
+
+func (b *mergeBaseBaseline) Name() string {
+	return BaselineMergeBase
+}
+
+func (b *mergeBaseBaseline) Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	if repository := runRepository(run); repository != nil && repository.Type != "" && repository.Type != RepositoryTypeAzureReposGit {
+		return nil, fmt.Errorf("%w: the merge-base is only read from Azure Repos, not from %s", ErrNoBaseline, repository.Type)
+	}
+	lastOnDefaultBranch, err := b.defaultBranch.Baseline(run, olderRuns)
+	if err != nil {
+		return nil, err
+	}
+	commitId, otherCommitId := sourceVersion(run), sourceVersion(*lastOnDefaultBranch)
+	if commitId == "" || otherCommitId == "" {
+		return nil, fmt.Errorf("%w: the source commit of the runs is unknown", ErrNoBaseline)
+	}
+
+	repositoryId := aliasRepositoryId(SelfRepositoryAlias, selfRepository(run), b.defaultBranch.repositoryId)
+	if repositoryId == "" {
+		return nil, fmt.Errorf("%w: %w", ErrNoBaseline, ErrUnknownRepository)
+	}
+
+	mergeBases, err := b.defaultBranch.usesCases.Repository.GetMergeBases(repositoryId, commitId, otherCommitId)
+	if err != nil {
+		return nil, err
+	}
+	if len(mergeBases) == 0 {
+		return nil, fmt.Errorf("%w: no merge-base between %s and %s", ErrNoBaseline, commitId, otherCommitId)
+	}
+	mergeBase := mergeBases[0].CommitId
+	return firstRun(olderRuns, fmt.Errorf("%w: no run built the merge-base %s", ErrNoBaseline, mergeBase), func(pre model.PipelineRuns) bool {
+		return sourceVersion(pre) == mergeBase
+	})
+}
+
+func (b *tagBaseline) Name() string {
+	return BaselineTag
+}
+
+func (b *tagBaseline) Baseline(run model.PipelineRuns, olderRuns []model.PipelineRuns) (*model.PipelineRuns, error) {
+	return firstRun(olderRuns, fmt.Errorf("%w: no older run tagged %s", ErrNoBaseline, b.tag), func(pre model.PipelineRuns) bool {
+		return slices.Contains(pre.Tags, b.tag)
+	})
+}
+
+// firstRun returns the first run matching the predicate, or notFound
+func firstRun(runs []model.PipelineRuns, notFound error, predicate func(model.PipelineRuns) bool) (*model.PipelineRuns, error) {
+	for _, run := range runs {
+		if predicate(run) {
+			return &run, nil
+		}
+	}
+	return nil, notFound
+}
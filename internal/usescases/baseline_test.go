@@ -0,0 +1,190 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRunOnCommit(ref string, name string, id int, commitId string) model.PipelineRuns {
+	run := createPipelineRun(ref, name, id)
+	run.Resources.Repositories[SelfRepositoryAlias] = model.RepositoryResource{RefName: ref, Version: commitId}
+	return run
+}
+
+func TestGetRunsToUpdate_ShouldReturnError_WithoutDefaultBranchRun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/feature-1", "", 3),
+		createPipelineRun("refs/heads/feature-2", "", 2),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrNoBaseline)
+	assert.ErrorContains(t, err, "same-ref: no baseline run found: no older run on refs/heads/feature-1")
+	assert.ErrorContains(t, err, "default-branch: no baseline run found: no older run on the default branch refs/heads/main")
+}
+
+func TestGetRunsToUpdate_ShouldReturnError_OnLastDefaultBranchRun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "", 2),
+		createPipelineRun("refs/heads/feature-1", "", 1),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	_, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
+
+	assert.ErrorIs(t, err, ErrNoBaseline)
+}
+
+func TestGetRunsToUpdate_LowerVersion(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.13", 4),
+		createPipelineRun("refs/heads/main", "25.4.13", 3),
+		createPipelineRun("refs/heads/release", "25.5.1", 2),
+		createPipelineRun("refs/heads/main", "25.4.12", 1),
+	}
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil, lowerVersionBaseline{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[3]}, result)
+}
+
+func TestGetRunsToUpdate_MergeBase(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/feature-1", "", 4, "feature"),
+		createRunOnCommit("refs/heads/main", "", 3, "main-head"),
+		createRunOnCommit("refs/heads/main", "", 2, "fork-point"),
+		createRunOnCommit("refs/heads/main", "", 1, "main-old"),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+	mockRepo.On("GetMergeBases", "repo-id", "feature", "main-head").Return([]model.GitCommitRef{{CommitId: "fork-point"}}, nil)
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil, &mergeBaseBaseline{defaultBranch: uc.newDefaultBranchBaseline("repo-id", "")})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[2]}, result)
+}
+
+func TestGetRunsToUpdate_MergeBaseNotBuilt(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/feature-1", "", 2, "feature"),
+		createRunOnCommit("refs/heads/main", "", 1, "main-head"),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+	mockRepo.On("GetMergeBases", "repo-id", "feature", "main-head").Return([]model.GitCommitRef{{CommitId: "fork-point"}}, nil)
+
+	_, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil, &mergeBaseBaseline{defaultBranch: uc.newDefaultBranchBaseline("repo-id", "")})
+
+	assert.ErrorIs(t, err, ErrNoBaseline)
+	assert.ErrorContains(t, err, "no run built the merge-base fork-point")
+}
+
+func TestGetRunsToUpdate_MergeBase_ShouldReadRepositoryOfRun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/feature-1", "", 2, "feature"),
+		createRunOnCommit("refs/heads/main", "", 1, "main-head"),
+	}
+	for _, build := range builds {
+		resource := build.Resources.Repositories[SelfRepositoryAlias]
+		resource.Repository = &model.RunRepository{Id: "run-repo-id"}
+		build.Resources.Repositories[SelfRepositoryAlias] = resource
+	}
+	mockRepo.On("GetRepositoryById", "run-repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+	mockRepo.On("GetMergeBases", "run-repo-id", "feature", "main-head").Return([]model.GitCommitRef{{CommitId: "main-head"}}, nil)
+
+	result, err := uc.getRunsToUpdate(builds, "", 123, nil, &mergeBaseBaseline{defaultBranch: uc.newDefaultBranchBaseline("", "")})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[1]}, result)
+}
+
+func TestGetRunsToUpdate_MergeBase_ShouldReturnError_WithoutRepository(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/feature-1", "", 2, "feature"),
+		createRunOnCommit("refs/heads/main", "", 1, "main-head"),
+	}
+
+	_, err := uc.getRunsToUpdate(builds, "", 123, nil, &mergeBaseBaseline{defaultBranch: uc.newDefaultBranchBaseline("", "refs/heads/main")})
+
+	assert.ErrorIs(t, err, ErrUnknownRepository)
+}
+
+func createTaggedRun(ref string, name string, id int, tags ...string) model.PipelineRuns {
+	run := createPipelineRun(ref, name, id)
+	run.Tags = tags
+	return run
+}
+
+func TestGetRunsToUpdate_Tag(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createTaggedRun("refs/heads/main", "", 3, "release"),
+		createTaggedRun("refs/heads/main", "", 2),
+		createTaggedRun("refs/heads/main", "", 1, "nightly", "release"),
+	}
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil, &tagBaseline{tag: "release"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[2]}, result)
+}
+
+func TestGetRunsToUpdate_ShouldTryNextStrategy(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.13", 2),
+		createPipelineRun("refs/heads/main", "25.4.12", 1),
+	}
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil, &tagBaseline{tag: "release"}, lowerVersionBaseline{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[1]}, result)
+}
+
+func TestFilterRuns_ShouldReadTheTagsOnce_WithTagBaseline(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "", 2),
+		createPipelineRun("refs/heads/main", "", 1),
+	}
+	mockRepo.On("GetBuildsByIds", []int{2, 1}).Return([]model.Build{{Id: 2}, {Id: 1, Tags: []string{"release"}}}, nil)
+
+	result, err := uc.filterRuns(runs, UpdateFieldsParams{Baselines: []string{BaselineTag}, BaselineTag: "release"}, nil, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"release"}, result[1].Tags)
+	mockRepo.AssertNumberOfCalls(t, "GetBuildsByIds", 1)
+}
+
+func TestBaselineStrategies(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+
+	strategies, err := uc.baselineStrategies(UpdateFieldsParams{Baselines: []string{BaselineTag, BaselineSameRef}, BaselineTag: "release"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{BaselineTag, BaselineSameRef}, []string{strategies[0].Name(), strategies[1].Name()})
+
+	_, err = uc.baselineStrategies(UpdateFieldsParams{Baselines: []string{BaselineTag}})
+	assert.ErrorIs(t, err, ErrInvalidBaseline)
+
+	_, err = uc.baselineStrategies(UpdateFieldsParams{Baselines: []string{"previous"}})
+	assert.ErrorIs(t, err, ErrInvalidBaseline)
+}
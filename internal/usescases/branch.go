@@ -0,0 +1,96 @@
+package usescases
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	BranchMatchExact string = "exact"
+	BranchMatchGlob  string = "glob"
+	BranchMatchRegex string = "regex"
+
+	AdoBranchRefPrefix string = "refs/heads/"
+)
+
+type (
+	// BranchMatcher selects the runs of a branch by their ref name
+	BranchMatcher interface {
+		Match(refName string) bool
+	}
+
+	exactBranch string
+	globBranch  string
+	regexBranch struct {
+		*regexp.Regexp
+	}
+)
+
+// NewBranchMatcher returns the matcher of pattern in mode exact, glob or regex, exact when mode is empty
+// Exact and glob patterns are branch names or full refs, regex patterns are matched against the branch name
+func NewBranchMatcher(mode string, pattern string) (BranchMatcher, error) {
+	switch mode {
+	case "", BranchMatchExact:
+		return ExactBranch(pattern), nil
+	case BranchMatchGlob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		return globBranch(normalizeRef(pattern)), nil
+	case BranchMatchRegex:
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return regexBranch{expression}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidBranchMatch, mode)
+}
+
+// ExactBranch matches a single branch, main and refs/heads/main are the same branch
+func ExactBranch(name string) BranchMatcher {
+	return exactBranch(normalizeRef(name))
+}
+
+func (b exactBranch) Match(refName string) bool {
+	return string(b) == normalizeRef(refName)
+}
+
+// Match uses path.Match, so * doesn't match a /
+func (b globBranch) Match(refName string) bool {
+	ok, _ := path.Match(string(b), normalizeRef(refName))
+	return ok
+}
+
+func (b regexBranch) Match(refName string) bool {
+	return b.MatchString(strings.TrimPrefix(refName, AdoBranchRefPrefix))
+}
+
+// normalizeRef returns the full ref of a branch name, refs like refs/tags/v1 are kept as is
+func normalizeRef(name string) string {
+	if strings.HasPrefix(name, "refs/") {
+		return name
+	}
+	return AdoBranchRefPrefix + name
+}
+
+// branchMatcher returns the matcher of param.BranchName, nil when no branch is selected
+func (param UpdateFieldsParams) branchMatcher() (BranchMatcher, error) {
+	if param.BranchName == "" {
+		return nil, nil
+	}
+	return NewBranchMatcher(param.BranchMatch, param.BranchName)
+}
+
+// runBranch returns the branch shown to the users: param.BranchName when an exact branch is selected,
+// otherwise the branch the run was built from
+func (param UpdateFieldsParams) runBranch(run model.PipelineRuns) string {
+	if param.BranchName != "" && (param.BranchMatch == "" || param.BranchMatch == BranchMatchExact) {
+		return param.BranchName
+	}
+	return strings.TrimPrefix(runRefName(run), AdoBranchRefPrefix)
+}
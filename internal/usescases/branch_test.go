@@ -0,0 +1,106 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchMatcher(t *testing.T) {
+	tests := map[string]struct {
+		mode     string
+		pattern  string
+		refName  string
+		expected bool
+	}{
+		"exact branch":          {mode: BranchMatchExact, pattern: "release/25.4", refName: "refs/heads/release/25.4", expected: true},
+		"exact other branch":    {mode: BranchMatchExact, pattern: "release/25.4", refName: "refs/heads/release/25.4.1-hotfix", expected: false},
+		"exact full ref":        {mode: "", pattern: "refs/heads/main", refName: "refs/heads/main", expected: true},
+		"exact prefix":          {mode: "", pattern: "refs/heads/main", refName: "refs/heads/maintenance", expected: false},
+		"exact tag":             {mode: BranchMatchExact, pattern: "refs/tags/v1", refName: "refs/tags/v1", expected: true},
+		"glob branch":           {mode: BranchMatchGlob, pattern: "release/*", refName: "refs/heads/release/25.4", expected: true},
+		"glob nested branch":    {mode: BranchMatchGlob, pattern: "release/*", refName: "refs/heads/release/25.4/fix", expected: false},
+		"glob other branch":     {mode: BranchMatchGlob, pattern: "release/*", refName: "refs/heads/main", expected: false},
+		"regex branch":          {mode: BranchMatchRegex, pattern: `^release/\d+\.\d+$`, refName: "refs/heads/release/25.4", expected: true},
+		"regex other branch":    {mode: BranchMatchRegex, pattern: `^release/\d+\.\d+$`, refName: "refs/heads/release/25.4.1-hotfix", expected: false},
+		"regex on full ref tag": {mode: BranchMatchRegex, pattern: `^refs/tags/`, refName: "refs/tags/v1", expected: true},
+	}
+
+	for name, test := range tests {
+		t.Run("TestBranchMatcher_"+name, func(t *testing.T) {
+			matcher, err := NewBranchMatcher(test.mode, test.pattern)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, matcher.Match(test.refName))
+		})
+	}
+}
+
+func TestNewBranchMatcher_Errors(t *testing.T) {
+	_, err := NewBranchMatcher("contains", "main")
+	assert.ErrorIs(t, err, ErrInvalidBranchMatch)
+
+	_, err = NewBranchMatcher(BranchMatchGlob, "release/[")
+	assert.NotNil(t, err)
+
+	_, err = NewBranchMatcher(BranchMatchRegex, "release/(")
+	assert.NotNil(t, err)
+}
+
+func TestGetRunsToUpdate_ShouldNotMatchBranchPrefix(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/maintenance", "", 3),
+		createPipelineRun("refs/heads/main", "", 2),
+		createPipelineRun("refs/heads/main", "", 1),
+	}
+
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, ExactBranch("main"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, builds[1:], result)
+}
+
+func TestUpdateFieldsByBranches_ShouldUpdateEachBranch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetPipelineRuns", 7).Return([]model.PipelineRuns{}, errors.New("error"))
+
+	err := uc.UpdateFieldsByBranches(UpdateFieldsParams{PipelineId: 7, FieldName: "Custom"}, []string{"main", "develop"})
+
+	assert.ErrorContains(t, err, "branch main: error")
+	assert.ErrorContains(t, err, "branch develop: error")
+	mockRepo.AssertNumberOfCalls(t, "GetPipelineRuns", 2)
+}
+
+func TestUpdateFieldsByLastRuns_WithInvalidBranchMatch(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{FieldName: "Custom", BranchName: "main", BranchMatch: "prefix"})
+
+	assert.ErrorIs(t, err, ErrInvalidBranchMatch)
+}
+
+func TestRunBranch(t *testing.T) {
+	run := createPipelineRun("refs/heads/release/25.4", "", 1)
+	tests := map[string]struct {
+		param    UpdateFieldsParams
+		expected string
+	}{
+		"without branch": {param: UpdateFieldsParams{}, expected: "release/25.4"},
+		"exact branch":   {param: UpdateFieldsParams{BranchName: "release/25.4"}, expected: "release/25.4"},
+		"glob branch":    {param: UpdateFieldsParams{BranchName: "release/*", BranchMatch: BranchMatchGlob}, expected: "release/25.4"},
+	}
+
+	for name, test := range tests {
+		t.Run("TestRunBranch_"+name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.param.runBranch(run))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package usescases
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
+)
+
+// RunState keeps the last run processed by the catch-up mode, by pipeline and ref
+type RunState interface {
+	Load(key string) (*model.RunCheckpoint, error)
+	Save(checkpoint model.RunCheckpoint) error
+}
+
+// catchUp processes each pair of consecutive runs since the last processed run, the oldest first,
+// so a work item gets the version of the first run that included it
+// Without checkpoint, or when the checkpoint run is no longer listed, only the last run is processed
+// The checkpoint is saved after each pair, a failed pair is processed again by the next invocation
+func (u *AdoUsesCases) catchUp(param UpdateFieldsParams, branch BranchMatcher) error {
+	if u.State == nil {
+		return ErrRunStateNotConfigured
+	}
+	result, err := u.Repository.GetPipelineRuns(param.PipelineId)
+	if err != nil {
+		return err
+	}
+	strategies, err := u.baselineStrategies(param)
+	if err != nil {
+		return err
+	}
+	if result, err = u.filterRuns(result, param, branch, u.catchUpRunsFound(param, branch, strategies)); err != nil {
+		return err
+	} else if len(result) == 0 {
+		return nil
+	}
+
+	index := findBranchRun(result, branch)
+	if index < 0 {
+		return ErrBranchNameNotExist
+	}
+	refName := runRefName(result[index])
+	runs := queryslice.Filter(result, func(pre model.PipelineRuns) bool {
+		return runRefName(pre) == refName
+	})
+
+	key := runStateKey(param, refName)
+	checkpoint, err := u.State.Load(key)
+	if err != nil {
+		return err
+	}
+
+	position := -1
+	if checkpoint != nil {
+		position = queryslice.FindIndex(runs, func(pre model.PipelineRuns) bool {
+			return pre.Id == checkpoint.LastRunId
+		})
+		if position < 0 {
+			u.logCatchUpWarn(fmt.Errorf("%w: run %d", ErrCheckpointRunNotFound, checkpoint.LastRunId), refName)
+		}
+	}
+
+	if position < 0 {
+		builds, err := u.getRunsToUpdate(result, param.RepositoryId, param.PipelineId, ExactBranch(refName), strategies...)
+		if err != nil {
+			return err
+		}
+		if err := u.updateFieldsForRuns(builds, param); err != nil {
+			return err
+		}
+		return u.saveCheckpoint(key, param.PipelineId, refName, builds[0])
+	}
+
+	for i := position - 1; i >= 0; i-- {
+		if err := u.updateFieldsForRuns([]model.PipelineRuns{runs[i], runs[i+1]}, param); err != nil {
+			return fmt.Errorf("run %d: %w", runs[i].Id, err)
+		}
+		if err := u.saveCheckpoint(key, param.PipelineId, refName, runs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catchUpRunsFound tells if runs reach the checkpoint of the ref of the last run, and are enough to find its baseline
+// when the checkpoint run is no longer listed or there is no checkpoint
+func (u *AdoUsesCases) catchUpRunsFound(param UpdateFieldsParams, branch BranchMatcher, strategies []BaselineStrategy) func([]model.PipelineRuns) bool {
+	runsToUpdateFound := u.runsToUpdateFound(param.RepositoryId, branch, strategies)
+	return func(runs []model.PipelineRuns) bool {
+		index := findBranchRun(runs, branch)
+		if index < 0 {
+			return false
+		}
+		refName := runRefName(runs[index])
+		checkpoint, err := u.State.Load(runStateKey(param, refName))
+		if err != nil {
+			// catchUp returns the error
+			return true
+		}
+		return runsToUpdateFound(runs) && (checkpoint == nil || slices.ContainsFunc(runs, func(pre model.PipelineRuns) bool {
+			return runRefName(pre) == refName && pre.Id <= checkpoint.LastRunId
+		}))
+	}
+}
+
+func (u *AdoUsesCases) saveCheckpoint(key string, pipelineId int, refName string, run model.PipelineRuns) error {
+	return u.State.Save(model.RunCheckpoint{
+		Key:         key,
+		PipelineId:  pipelineId,
+		RefName:     refName,
+		LastRunId:   run.Id,
+		LastRunName: run.Name,
+		UpdatedAt:   time.Now().UTC(),
+	})
+}
+
+// runStateKey returns the key of the checkpoint of a ref, escaped to be usable as a file name
+// The runs waiting on a stage or an environment have their own checkpoint, so each environment is caught up separately
+func runStateKey(param UpdateFieldsParams, refName string) string {
+	key := fmt.Sprintf("%d-%s", param.PipelineId, url.PathEscape(refName))
+	if param.Stage == nil {
+		return key
+	}
+	if param.Stage.Environment != "" {
+		key += "-environment-" + url.PathEscape(param.Stage.Environment)
+	}
+	if param.Stage.Stage != "" {
+		key += "-stage-" + url.PathEscape(param.Stage.Stage)
+	}
+	return key
+}
+
+func (u *AdoUsesCases) logCatchUpWarn(err error, refName string) {
+	if u.Logger == nil {
+		return
+	}
+	u.Logger.Warn().
+		Err(err).
+		Str("ref-name", refName).
+		Msg("Only the last run is processed")
+}
@@ -0,0 +1,108 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRunState keeps the checkpoints in memory
+type MockRunState struct {
+	checkpoints map[string]model.RunCheckpoint
+	saved       []int
+}
+
+func (m *MockRunState) Load(key string) (*model.RunCheckpoint, error) {
+	checkpoint, ok := m.checkpoints[key]
+	if !ok {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+func (m *MockRunState) Save(checkpoint model.RunCheckpoint) error {
+	m.checkpoints[checkpoint.Key] = checkpoint
+	m.saved = append(m.saved, checkpoint.LastRunId)
+	return nil
+}
+
+func newCatchUpRuns() []model.PipelineRuns {
+	return []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.4", 4),
+		createPipelineRun("refs/heads/main", "25.4.3", 3),
+		createPipelineRun("refs/heads/main", "25.4.2", 2),
+		createPipelineRun("refs/heads/main", "25.4.1", 1),
+	}
+}
+
+func TestCatchUp_ShouldProcessEachRunSinceCheckpoint(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	state := &MockRunState{checkpoints: map[string]model.RunCheckpoint{
+		"862-refs%2Fheads%2Fmain": {Key: "862-refs%2Fheads%2Fmain", LastRunId: 2},
+	}}
+	uc := AdoUsesCases{Repository: mockRepo, State: state}
+
+	mockRepo.On("GetPipelineRuns", 862).Return(newCatchUpRuns(), nil)
+	mockRepo.On("GetBuildWorkItem", 2, 3).Return([]model.BuildWorkItems{{Id: "1"}}, nil)
+	mockRepo.On("GetBuildWorkItem", 3, 4).Return([]model.BuildWorkItems{{Id: "1"}, {Id: "2"}}, nil)
+	mockRepo.On("GetBuildChanges", mock.Anything, mock.Anything).Return([]model.BuildChanges{}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, map[string]interface{}{"Custom": ""}), nil).Once()
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, map[string]interface{}{"Custom": "25.4.3"}), nil)
+	mockRepo.On("GetWorkItem", "2").Return(createWorkItem(2, map[string]interface{}{"Custom": ""}), nil)
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{PipelineId: 862, RepositoryId: "62", FieldName: "/fields/Custom", CatchUp: true})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 4}, state.saved)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "1", model.OperationFields{Op: "add", Path: "/fields/Custom", Value: "25.4.3"})
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "2", model.OperationFields{Op: "add", Path: "/fields/Custom", Value: "25.4.4"})
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemField", "1", model.OperationFields{Op: "add", Path: "/fields/Custom", Value: "25.4.4"})
+	mockRepo.AssertNotCalled(t, "GetBuildWorkItem", 1, 2)
+}
+
+func TestCatchUp_WithoutCheckpoint_ShouldProcessLastRun(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	state := &MockRunState{checkpoints: map[string]model.RunCheckpoint{}}
+	uc := AdoUsesCases{Repository: mockRepo, State: state}
+
+	mockRepo.On("GetPipelineRuns", 862).Return(newCatchUpRuns(), nil)
+	mockRepo.On("GetRepositoryById", "62").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+	mockRepo.On("GetBuildWorkItem", 3, 4).Return([]model.BuildWorkItems{}, nil)
+	mockRepo.On("GetBuildChanges", 3, 4).Return([]model.BuildChanges{}, nil)
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{PipelineId: 862, RepositoryId: "62", FieldName: "/fields/Custom", CatchUp: true})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4}, state.saved)
+	assert.Equal(t, "25.4.4", state.checkpoints["862-refs%2Fheads%2Fmain"].LastRunName)
+}
+
+func TestCatchUp_ShouldKeepCheckpoint_OnError(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	state := &MockRunState{checkpoints: map[string]model.RunCheckpoint{
+		"862-refs%2Fheads%2Fmain": {Key: "862-refs%2Fheads%2Fmain", LastRunId: 2},
+	}}
+	uc := AdoUsesCases{Repository: mockRepo, State: state}
+
+	mockRepo.On("GetPipelineRuns", 862).Return(newCatchUpRuns(), nil)
+	mockRepo.On("GetBuildWorkItem", 2, 3).Return([]model.BuildWorkItems{}, nil)
+	mockRepo.On("GetBuildWorkItem", 3, 4).Return([]model.BuildWorkItems{}, errors.New("error"))
+	mockRepo.On("GetBuildChanges", mock.Anything, mock.Anything).Return([]model.BuildChanges{}, nil)
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{PipelineId: 862, RepositoryId: "62", FieldName: "/fields/Custom", CatchUp: true})
+
+	assert.EqualError(t, err, "run 4: error")
+	assert.Equal(t, []int{3}, state.saved)
+}
+
+func TestCatchUp_ShouldReturnError_WithoutState(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{PipelineId: 862, FieldName: "/fields/Custom", CatchUp: true})
+
+	assert.ErrorIs(t, err, ErrRunStateNotConfigured)
+}
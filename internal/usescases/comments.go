@@ -0,0 +1,112 @@
+package usescases
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	AdoAssignedToFieldName string = "System.AssignedTo"
+
+	DefaultCommentTemplate string = "{{.Mention}}Version **{{.Version}}** integrated on `{{.Branch}}` by run [{{.Run.Name}}]({{.RunUrl}})."
+)
+
+type (
+	CommentParams struct {
+		Template *template.Template
+		// MentionAssignee adds an @mention of the assignee through .Mention
+		MentionAssignee bool
+	}
+
+	// CommentTemplateData is the data given to the comment template
+	CommentTemplateData struct {
+		Version  string
+		Branch   string
+		RunUrl   string
+		Mention  string
+		Run      model.PipelineRuns
+		Commits  []model.BuildChanges
+		WorkItem model.WorkItem
+		// Repositories are the aliases of the repositories whose commits link the work item, in a multi-repository pipeline
+		Repositories []string
+
+		repositories map[int][]string
+	}
+)
+
+// ParseCommentTemplate parses a Markdown comment template, the default one is used when text is empty
+func ParseCommentTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultCommentTemplate
+	}
+	return template.New("comment").Parse(text)
+}
+
+// commentMarker is hidden in the Markdown of the comment to find it again on reruns
+func commentMarker(version string) string {
+	return fmt.Sprintf("<!-- prev-updater:%s -->", version)
+}
+
+// addVersionComments comments each work item once per version
+func (u *AdoUsesCases) addVersionComments(workItems []model.WorkItem, data CommentTemplateData, param CommentParams) error {
+	var errMap error = nil
+	marker := commentMarker(data.Version)
+	for _, workItem := range workItems {
+		if workItem.Id == 0 {
+			continue
+		}
+		workItemId := strconv.Itoa(workItem.Id)
+		comments, err := u.Repository.GetWorkItemComments(workItemId)
+		if err != nil {
+			errMap = errors.Join(errMap, err)
+			continue
+		}
+		if hasComment(comments, marker) {
+			continue
+		}
+
+		data.WorkItem = workItem
+		data.Repositories = data.repositories[workItem.Id]
+		data.Mention = ""
+		if param.MentionAssignee {
+			data.Mention = mention(workItem)
+		}
+		var buffer bytes.Buffer
+		if err := param.Template.Execute(&buffer, data); err != nil {
+			errMap = errors.Join(errMap, err)
+			continue
+		}
+		if err := u.Repository.AddWorkItemComment(workItemId, buffer.String()+"\n\n"+marker); err != nil {
+			errMap = errors.Join(errMap, err)
+		}
+	}
+	return errMap
+}
+
+func hasComment(comments []model.WorkItemComment, marker string) bool {
+	for _, comment := range comments {
+		if strings.Contains(comment.Text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// mention returns the Markdown mention of the assignee followed by a space, or an empty string
+func mention(workItem model.WorkItem) string {
+	assignee, ok := workItem.Fields[AdoAssignedToFieldName].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	id, _ := assignee["id"].(string)
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("@<%s> ", id)
+}
@@ -0,0 +1,67 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddVersionComments_ShouldCommentOncePerVersion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	tmpl, _ := ParseCommentTemplate("")
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{}),
+		createWorkItem(2, map[string]interface{}{}),
+	}
+	mockRepo.On("GetWorkItemComments", "1").Return([]model.WorkItemComment{{Id: 1, Text: "Version 25.4.13\n\n" + commentMarker("25.4.13")}}, nil)
+	mockRepo.On("GetWorkItemComments", "2").Return([]model.WorkItemComment{{Id: 2, Text: "Version 25.4.1\n\n" + commentMarker("25.4.1")}}, nil)
+	mockRepo.On("AddWorkItemComment", "2", mock.Anything).Return(nil)
+
+	err := uc.addVersionComments(workItems, CommentTemplateData{
+		Version: "25.4.13",
+		Branch:  "refs/heads/main",
+		RunUrl:  "https://dev.azure.com/run/42",
+		Run:     model.PipelineRuns{Name: "25.4.13"},
+	}, CommentParams{Template: tmpl})
+
+	assert.Nil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "AddWorkItemComment", 1)
+	mockRepo.AssertCalled(t, "AddWorkItemComment", "2", "Version **25.4.13** integrated on `refs/heads/main` by run [25.4.13](https://dev.azure.com/run/42).\n\n"+commentMarker("25.4.13"))
+}
+
+func TestAddVersionComments_ShouldMentionAssignee(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	tmpl, _ := ParseCommentTemplate("{{.Mention}}{{.Version}}")
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{AdoAssignedToFieldName: map[string]interface{}{"id": "a1b2", "displayName": "Dev"}}),
+		createWorkItem(2, map[string]interface{}{}),
+	}
+	mockRepo.On("GetWorkItemComments", mock.Anything).Return([]model.WorkItemComment{}, nil)
+	mockRepo.On("AddWorkItemComment", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.addVersionComments(workItems, CommentTemplateData{Version: "25.4.13"}, CommentParams{Template: tmpl, MentionAssignee: true})
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "AddWorkItemComment", "1", "@<a1b2> 25.4.13\n\n"+commentMarker("25.4.13"))
+	mockRepo.AssertCalled(t, "AddWorkItemComment", "2", "25.4.13\n\n"+commentMarker("25.4.13"))
+}
+
+func TestAddVersionComments_ShouldReturnErrors(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	tmpl, _ := ParseCommentTemplate("")
+
+	mockRepo.On("GetWorkItemComments", "1").Return([]model.WorkItemComment{}, errors.New("error"))
+
+	err := uc.addVersionComments([]model.WorkItem{createWorkItem(1, nil)}, CommentTemplateData{Version: "25.4.13"}, CommentParams{Template: tmpl})
+
+	assert.NotNil(t, err)
+	mockRepo.AssertNotCalled(t, "AddWorkItemComment", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,37 @@
+package usescases
+
+import (
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+// getCommits returns the commits between the baseline builds[1] and the run builds[0]
+// The commits only enrich the release, an error is logged and no commit is returned
+func (u *AdoUsesCases) getCommits(builds []model.PipelineRuns) []model.BuildChanges {
+	commits, err := u.Repository.GetBuildChanges(builds[1].Id, builds[0].Id)
+	if err != nil {
+		if u.Logger != nil {
+			u.Logger.Warn().Err(err).Msg("GetBuildChanges")
+		}
+		return []model.BuildChanges{}
+	}
+	return commits
+}
+
+// commitsToN8nCommits maps the build changes to the commits of the release event
+func commitsToN8nCommits(commits []model.BuildChanges) []model.N8nCommit {
+	if len(commits) == 0 {
+		return nil
+	}
+	result := make([]model.N8nCommit, len(commits))
+	for index, commit := range commits {
+		result[index] = model.N8nCommit{
+			Id:        commit.Id,
+			Message:   commit.Message,
+			Author:    commit.Author.DisplayName,
+			Email:     commit.Author.UniqueName,
+			Timestamp: commit.Timestamp,
+			Url:       commit.DisplayUri,
+		}
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCommits_ShouldUseBaselineAndRun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	commits := []model.BuildChanges{{Id: "abc123", Message: "Fix login"}}
+
+	mockRepo.On("GetBuildChanges", 40, 42).Return(commits, nil)
+
+	result := uc.getCommits([]model.PipelineRuns{{Id: 42}, {Id: 40}})
+
+	assert.Equal(t, commits, result)
+}
+
+func TestGetCommits_ShouldIgnoreErrors(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetBuildChanges", 40, 42).Return(nil, errors.New("error"))
+
+	result := uc.getCommits([]model.PipelineRuns{{Id: 42}, {Id: 40}})
+
+	assert.Empty(t, result)
+}
+
+func TestCommitsToN8nCommits(t *testing.T) {
+	timestamp := time.Date(2025, 4, 13, 10, 0, 0, 0, time.UTC)
+
+	result := commitsToN8nCommits([]model.BuildChanges{
+		{
+			Id:         "abc123",
+			Message:    "Fix login",
+			Author:     model.IdentityRef{DisplayName: "Dev", UniqueName: "dev@example.com"},
+			Timestamp:  timestamp,
+			DisplayUri: "https://dev.azure.com/org/project/_git/repo/commit/abc123",
+		},
+	})
+
+	assert.Equal(t, []model.N8nCommit{
+		{
+			Id:        "abc123",
+			Message:   "Fix login",
+			Author:    "Dev",
+			Email:     "dev@example.com",
+			Timestamp: timestamp,
+			Url:       "https://dev.azure.com/org/project/_git/repo/commit/abc123",
+		},
+	}, result)
+	assert.Nil(t, commitsToN8nCommits(nil))
+}
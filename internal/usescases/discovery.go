@@ -0,0 +1,169 @@
+package usescases
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	AdoArtifactLinkRel string = "ArtifactLink"
+	AdoRelationsPath   string = "/relations/-"
+
+	commitArtifact      string = "Commit"
+	pullRequestArtifact string = "PullRequestId"
+)
+
+var (
+	// mentionPattern finds the work items mentioned in a commit message, like AB#1234
+	mentionPattern = regexp.MustCompile(`(?i)\bAB#(\d+)\b`)
+	// hashMentionPattern finds the bare mentions like #1234, which are also GitHub issues or pull requests
+	hashMentionPattern = regexp.MustCompile(`(?:^|[^\w#&])#(\d+)\b`)
+	// mergedPullRequestPattern finds the pull request of a merge commit made by ADO
+	mergedPullRequestPattern = regexp.MustCompile(`^Merged PR (\d+)`)
+	// branchPattern finds the work item of a branch like feature/1234-login
+	branchPattern = regexp.MustCompile(`(?:^|/)(\d+)[-_][^/]*$`)
+)
+
+type (
+	// DiscoveryParams finds the work items mentioned in the commits of the run range but not linked to it
+	DiscoveryParams struct {
+		// LinkMissing links each discovered work item to the commit or pull request that mentions it
+		LinkMissing bool
+		// HashMentions also takes the bare #1234 mentions as work items, not only AB#1234
+		HashMentions bool
+	}
+
+	// workItemReference is a work item mentioned by an artifact, a commit or a pull request
+	workItemReference struct {
+		WorkItemId   int
+		ArtifactType string
+		ArtifactId   string
+	}
+)
+
+// discoverWorkItems returns the work items mentioned in the commits and merged branches that are not in workItems
+// The references to unknown work items are logged and skipped
+func (u *AdoUsesCases) discoverWorkItems(workItems []model.WorkItem, commits []model.BuildChanges, repositoryId string, param DiscoveryParams) []model.WorkItem {
+	known := make(map[int]bool, len(workItems))
+	for _, workItem := range workItems {
+		known[workItem.Id] = true
+	}
+
+	projectId := ""
+	discovered := []model.WorkItem{}
+	for _, reference := range u.findWorkItemReferences(commits, param.HashMentions) {
+		if known[reference.WorkItemId] {
+			continue
+		}
+		known[reference.WorkItemId] = true
+
+		workItem, err := u.Repository.GetWorkItemWithRelations(strconv.Itoa(reference.WorkItemId))
+		if err != nil {
+			u.logWarn(err, reference.WorkItemId, "GetWorkItemWithRelations")
+			continue
+		}
+		discovered = append(discovered, *workItem)
+
+		if !param.LinkMissing {
+			continue
+		}
+		if projectId == "" {
+			repository, err := u.Repository.GetRepositoryById(repositoryId)
+			if err != nil {
+				u.logWarn(err, reference.WorkItemId, "GetRepositoryById")
+				continue
+			}
+			projectId = repository.Project.Id
+		}
+		url := artifactUrl(reference.ArtifactType, projectId, repositoryId, reference.ArtifactId)
+		if err := u.linkArtifact(*workItem, url, reference.ArtifactType); err != nil {
+			u.logWarn(err, reference.WorkItemId, "linkArtifact")
+		}
+	}
+	return discovered
+}
+
+// findWorkItemReferences returns the work items mentioned by the commit messages and the source branches of the merged pull requests
+func (u *AdoUsesCases) findWorkItemReferences(commits []model.BuildChanges, hashMentions bool) []workItemReference {
+	references := []workItemReference{}
+	for _, commit := range commits {
+		for _, id := range workItemIdsInMessage(commit.Message, hashMentions) {
+			references = append(references, workItemReference{WorkItemId: id, ArtifactType: commitArtifact, ArtifactId: commit.Id})
+		}
+
+		match := mergedPullRequestPattern.FindStringSubmatch(commit.Message)
+		if match == nil {
+			continue
+		}
+		pullRequestId, _ := strconv.Atoi(match[1])
+		pullRequest, err := u.Repository.GetPullRequest(pullRequestId)
+		if err != nil {
+			if u.Logger != nil {
+				u.Logger.Warn().Err(err).Int("pull-request-id", pullRequestId).Msg("GetPullRequest")
+			}
+			continue
+		}
+		if id, ok := workItemIdInBranch(pullRequest.SourceRefName); ok {
+			references = append(references, workItemReference{WorkItemId: id, ArtifactType: pullRequestArtifact, ArtifactId: match[1]})
+		}
+	}
+	return references
+}
+
+// workItemIdsInMessage returns the work items mentioned like AB#1234, and like #1234 when hashMentions is true
+func workItemIdsInMessage(message string, hashMentions bool) []int {
+	patterns := []*regexp.Regexp{mentionPattern}
+	if hashMentions {
+		patterns = append(patterns, hashMentionPattern)
+	}
+	ids := []int{}
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(message, -1) {
+			if id, err := strconv.Atoi(match[1]); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func workItemIdInBranch(refName string) (int, bool) {
+	match := branchPattern.FindStringSubmatch(refName)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(match[1])
+	return id, err == nil
+}
+
+// artifactUrl returns the url of a Git commit or pull request as expected by an ArtifactLink relation
+func artifactUrl(artifactType, projectId, repositoryId, artifactId string) string {
+	return fmt.Sprintf("vstfs:///Git/%s/%s%%2F%s%%2F%s", artifactType, projectId, repositoryId, artifactId)
+}
+
+// linkArtifact adds the ArtifactLink relation to url, unless the work item already has it
+func (u *AdoUsesCases) linkArtifact(workItem model.WorkItem, url string, artifactType string) error {
+	for _, relation := range workItem.Relations {
+		if relation.Rel == AdoArtifactLinkRel && relation.Url == url {
+			return nil
+		}
+	}
+	name := "Fixed in Commit"
+	if artifactType == pullRequestArtifact {
+		name = "Pull Request"
+	}
+	return u.Repository.UpdateWorkitemFields(strconv.Itoa(workItem.Id), []model.OperationFields{
+		{
+			Op:   "add",
+			Path: AdoRelationsPath,
+			Value: model.WorkItemRelation{
+				Rel:        AdoArtifactLinkRel,
+				Url:        url,
+				Attributes: map[string]interface{}{"name": name},
+			},
+		},
+	})
+}
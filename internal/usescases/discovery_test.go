@@ -0,0 +1,127 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWorkItemIdsInMessage(t *testing.T) {
+	tests := map[string]struct {
+		message      string
+		hashMentions bool
+		expected     []int
+	}{
+		"AB mention":             {message: "Fix login AB#1234", expected: []int{1234}},
+		"lower case":             {message: "ab#12 fix", expected: []int{12}},
+		"hash mention ignored":   {message: "fixes #12, see #43", expected: []int{}},
+		"hash mention":           {message: "Fix #42 and #43", hashMentions: true, expected: []int{42, 43}},
+		"both mentions":          {message: "AB#1 (#2)", hashMentions: true, expected: []int{1, 2}},
+		"AB mention, hash unset": {message: "AB#1 (#2)", expected: []int{1}},
+		"html entity":            {message: "Fix &#39;quote&#39;", hashMentions: true, expected: []int{}},
+		"without a mention":      {message: "Merged PR 12: Fix login", expected: []int{}},
+	}
+
+	for name, test := range tests {
+		t.Run("TestWorkItemIdsInMessage_"+name, func(t *testing.T) {
+			assert.Equal(t, test.expected, workItemIdsInMessage(test.message, test.hashMentions))
+		})
+	}
+}
+
+func TestWorkItemIdInBranch(t *testing.T) {
+	tests := map[string]struct {
+		refName    string
+		expectedId int
+		expectedOk bool
+	}{
+		"feature branch": {refName: "refs/heads/feature/1234-login", expectedId: 1234, expectedOk: true},
+		"underscore":     {refName: "refs/heads/users/dev/56_fix", expectedId: 56, expectedOk: true},
+		"release branch": {refName: "refs/heads/release/2024", expectedOk: false},
+		"without an id":  {refName: "refs/heads/main", expectedOk: false},
+	}
+
+	for name, test := range tests {
+		t.Run("TestWorkItemIdInBranch_"+name, func(t *testing.T) {
+			id, ok := workItemIdInBranch(test.refName)
+
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expectedId, id)
+		})
+	}
+}
+
+func TestDiscoverWorkItems_ShouldAddMentionedWorkItems(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	commits := []model.BuildChanges{
+		{Id: "abc123", Message: "Fix login AB#2 AB#1"},
+		{Id: "def456", Message: "Merged PR 7: Add logout"},
+		{Id: "ghi789", Message: "Refactor #404"},
+	}
+
+	mockRepo.On("GetPullRequest", 7).Return(model.PullRequest{PullRequestId: 7, SourceRefName: "refs/heads/feature/3-logout"}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "2").Return(createWorkItem(2, nil), nil)
+	mockRepo.On("GetWorkItemWithRelations", "3").Return(createWorkItem(3, nil), nil)
+
+	result := uc.discoverWorkItems([]model.WorkItem{createWorkItem(1, nil)}, commits, "repo-id", DiscoveryParams{})
+
+	assert.Equal(t, []model.WorkItem{createWorkItem(2, nil), createWorkItem(3, nil)}, result)
+	mockRepo.AssertNotCalled(t, "GetWorkItemWithRelations", "404")
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemFields", mock.Anything, mock.Anything)
+}
+
+func TestDiscoverWorkItems_ShouldAddHashMentions_WhenEnabled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	commits := []model.BuildChanges{
+		{Id: "abc123", Message: "Fix login AB#2"},
+		{Id: "ghi789", Message: "Refactor #404 and #5"},
+	}
+
+	mockRepo.On("GetWorkItemWithRelations", "2").Return(createWorkItem(2, nil), nil)
+	mockRepo.On("GetWorkItemWithRelations", "404").Return(nil, errors.New("not found"))
+	mockRepo.On("GetWorkItemWithRelations", "5").Return(createWorkItem(5, nil), nil)
+
+	result := uc.discoverWorkItems([]model.WorkItem{}, commits, "repo-id", DiscoveryParams{HashMentions: true})
+
+	assert.Equal(t, []model.WorkItem{createWorkItem(2, nil), createWorkItem(5, nil)}, result)
+}
+
+func TestDiscoverWorkItems_ShouldLinkMissingArtifacts(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	commitUrl := "vstfs:///Git/Commit/project-id%2Frepo-id%2Fabc123"
+	pullRequestUrl := "vstfs:///Git/PullRequestId/project-id%2Frepo-id%2F7"
+	commits := []model.BuildChanges{
+		{Id: "abc123", Message: "Fix login AB#2"},
+		{Id: "def456", Message: "Merged PR 7: Add logout"},
+	}
+	linked := createWorkItem(3, nil)
+	linked.Relations = []model.WorkItemRelation{{Rel: AdoArtifactLinkRel, Url: pullRequestUrl}}
+
+	mockRepo.On("GetPullRequest", 7).Return(model.PullRequest{SourceRefName: "refs/heads/feature/3-logout"}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "2").Return(createWorkItem(2, nil), nil)
+	mockRepo.On("GetWorkItemWithRelations", "3").Return(linked, nil)
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{Project: model.ProjectReference{Id: "project-id"}}, nil).Once()
+	mockRepo.On("UpdateWorkitemFields", "2", []model.OperationFields{
+		{
+			Op:   "add",
+			Path: AdoRelationsPath,
+			Value: model.WorkItemRelation{
+				Rel:        AdoArtifactLinkRel,
+				Url:        commitUrl,
+				Attributes: map[string]interface{}{"name": "Fixed in Commit"},
+			},
+		},
+	}).Return(nil)
+
+	result := uc.discoverWorkItems([]model.WorkItem{}, commits, "repo-id", DiscoveryParams{LinkMissing: true})
+
+	assert.Len(t, result, 2)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "UpdateWorkitemFields", 1)
+}
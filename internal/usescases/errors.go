@@ -3,5 +3,26 @@ package usescases
 import "errors"
 
 var (
-	ErrBranchNameNotExist error = errors.New("the branch name doesn't exist in git repository")
+	ErrBranchNameNotExist          error = errors.New("the branch name doesn't exist in git repository")
+	ErrNoUpdateTarget              error = errors.New("neither a field, a field mapping nor a version tag to update")
+	ErrInvalidFieldMapping         error = errors.New("invalid field mapping, expected 'Field[:type]=template'")
+	ErrInvalidFieldType            error = errors.New("invalid field type, expected string, number or date")
+	ErrSinkNotConfigured           error = errors.New("notification sink not configured")
+	ErrInvalidRunRange             error = errors.New("invalid run range, the from-run or since date must be before the to-run or until date")
+	ErrRunNotInPipeline            error = errors.New("the run doesn't belong to the pipeline")
+	ErrNoRunInRange                error = errors.New("no run in the range")
+	ErrInvalidBranchMatch          error = errors.New("invalid branch match, expected exact, glob or regex")
+	ErrInvalidWorkItemSource       error = errors.New("invalid work item source, expected build or pull-requests")
+	ErrInvalidBaseline             error = errors.New("invalid baseline, expected same-ref, default-branch, lower-version, merge-base or tag")
+	ErrRunStateNotConfigured       error = errors.New("the catch-up mode needs a run state")
+	ErrCheckpointRunNotFound       error = errors.New("the last processed run is no longer listed")
+	ErrInvalidEnvironmentField     error = errors.New("invalid environment field, expected 'Environment=Field'")
+	ErrInvalidRerunRule            error = errors.New("invalid reruns rule, expected newest or oldest")
+	ErrInvalidIntegrationSeparator error = errors.New("the separator of the versions history can't be empty")
+	ErrInvalidIntegrationSort      error = errors.New("invalid versions history sort, expected none, asc or desc")
+	ErrUnknownDefaultBranch        error = errors.New("the default branch of the repository is unknown, set it with the default branch option")
+	ErrNoBaseline                  error = errors.New("no baseline run found")
+	ErrUnknownRepository           error = errors.New("the Azure Repos repository of the run is unknown, set it with the repository option")
+	ErrInvalidPathFilter           error = errors.New("invalid path filter, expected a glob like 'services/api/**' or '!**/*.md'")
+	ErrPathFilterUnavailable       error = errors.New("the path filter needs the source commits of the runs on Azure Repos")
 )
@@ -0,0 +1,137 @@
+package usescases
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	FieldTypeString string = "string"
+	FieldTypeNumber string = "number"
+	FieldTypeDate   string = "date"
+
+	// goTimeLayout is the layout used when a time.Time is rendered as is by a template
+	goTimeLayout string = "2006-01-02 15:04:05.999999999 -0700 MST"
+)
+
+type (
+	// FieldMapping sets the field at Path with the rendered Template converted to Type
+	FieldMapping struct {
+		Path     string
+		Type     string
+		Template *template.Template
+	}
+
+	// FieldTemplateData is the data given to the templates of the field mappings
+	FieldTemplateData struct {
+		Version  string
+		Branch   string
+		Run      model.PipelineRuns
+		Baseline model.PipelineRuns
+		Commits  []model.BuildChanges
+		WorkItem model.WorkItem
+	}
+)
+
+// ParseFieldMapping parses a mapping like 'Custom.IntegratedOn:date={{.Run.FinishedDate}}'
+func ParseFieldMapping(mapping string) (FieldMapping, error) {
+	field, text, ok := strings.Cut(mapping, "=")
+	if !ok || field == "" {
+		return FieldMapping{}, ErrInvalidFieldMapping
+	}
+
+	fieldType := FieldTypeString
+	if name, kind, ok := strings.Cut(field, ":"); ok {
+		field, fieldType = name, kind
+	}
+	if fieldType != FieldTypeString && fieldType != FieldTypeNumber && fieldType != FieldTypeDate {
+		return FieldMapping{}, ErrInvalidFieldType
+	}
+
+	tmpl, err := template.New(field).Parse(text)
+	if err != nil {
+		return FieldMapping{}, err
+	}
+
+	path := field
+	if !strings.HasPrefix(path, "/fields/") {
+		path = "/fields/" + strings.TrimPrefix(path, "/")
+	}
+	return FieldMapping{
+		Path:     path,
+		Type:     fieldType,
+		Template: tmpl,
+	}, nil
+}
+
+// Render executes the template with data and converts the result to the type of the field
+// A string field holding only a time.Time rendered as is, like {{.Run.FinishedDate}}, is sent as RFC3339 so ADO accepts it in a date field
+func (f FieldMapping) Render(data FieldTemplateData) (interface{}, error) {
+	var buffer bytes.Buffer
+	if err := f.Template.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	value := strings.TrimSpace(buffer.String())
+
+	switch f.Type {
+	case FieldTypeNumber:
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return number, nil
+		}
+		return strconv.ParseFloat(value, 64)
+	case FieldTypeDate:
+		return parseDate(value)
+	default:
+		if date, err := time.Parse(goTimeLayout, value); err == nil {
+			return date.Format(time.RFC3339Nano), nil
+		}
+		return value, nil
+	}
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, goTimeLayout, time.DateTime, time.DateOnly} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a date", value)
+}
+
+// applyFieldMappings renders every mapping for each work item and sends them in one update
+func (u *AdoUsesCases) applyFieldMappings(workItems []model.WorkItem, mappings []FieldMapping, data FieldTemplateData) error {
+	var errMap error = nil
+	for _, workItem := range workItems {
+		if workItem.Id == 0 {
+			continue
+		}
+		data.WorkItem = workItem
+		operations := make([]model.OperationFields, 0, len(mappings))
+		for _, mapping := range mappings {
+			value, err := mapping.Render(data)
+			if err != nil {
+				errMap = errors.Join(errMap, fmt.Errorf("work item %d, field %s: %w", workItem.Id, mapping.Path, err))
+				continue
+			}
+			operations = append(operations, model.OperationFields{
+				Op:    "add",
+				Path:  mapping.Path,
+				Value: value,
+			})
+		}
+		if len(operations) == 0 {
+			continue
+		}
+		if err := u.Repository.UpdateWorkitemFields(strconv.Itoa(workItem.Id), operations); err != nil {
+			errMap = errors.Join(errMap, err)
+		}
+	}
+	return errMap
+}
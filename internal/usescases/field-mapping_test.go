@@ -0,0 +1,107 @@
+package usescases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFieldMapping(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      string
+		expectedPath string
+		expectedType string
+		expectedErr  error
+	}{
+		{name: "Default", mapping: "Custom.TargetVersion={{.Version}}", expectedPath: "/fields/Custom.TargetVersion", expectedType: FieldTypeString},
+		{name: "WithPath", mapping: "/fields/Custom.TargetVersion={{.Version}}", expectedPath: "/fields/Custom.TargetVersion", expectedType: FieldTypeString},
+		{name: "Date", mapping: "Custom.IntegratedOn:date={{.Run.FinishedDate}}", expectedPath: "/fields/Custom.IntegratedOn", expectedType: FieldTypeDate},
+		{name: "Number", mapping: "Custom.RunId:number={{.Run.Id}}", expectedPath: "/fields/Custom.RunId", expectedType: FieldTypeNumber},
+		{name: "MissingEqual", mapping: "Custom.TargetVersion", expectedErr: ErrInvalidFieldMapping},
+		{name: "MissingField", mapping: "={{.Version}}", expectedErr: ErrInvalidFieldMapping},
+		{name: "UnknownType", mapping: "Custom.X:bool=true", expectedErr: ErrInvalidFieldType},
+	}
+
+	for _, test := range tests {
+		t.Run("TestParseFieldMapping_"+test.name, func(t *testing.T) {
+			mapping, err := ParseFieldMapping(test.mapping)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedPath, mapping.Path)
+			assert.Equal(t, test.expectedType, mapping.Type)
+		})
+	}
+}
+
+func TestParseFieldMapping_InvalidTemplate(t *testing.T) {
+	_, err := ParseFieldMapping("Custom.X={{.Version")
+
+	assert.NotNil(t, err)
+}
+
+func TestFieldMappingRender(t *testing.T) {
+	finishedDate := time.Date(2025, 4, 13, 10, 30, 0, 0, time.UTC)
+	data := FieldTemplateData{
+		Version:  "25.4.13",
+		Run:      model.PipelineRuns{Id: 42, FinishedDate: finishedDate},
+		WorkItem: createWorkItem(1, map[string]interface{}{AdoTitleFieldName: "Title"}),
+	}
+	tests := []struct {
+		name     string
+		mapping  string
+		expected interface{}
+	}{
+		{name: "String", mapping: "Custom.X=v{{.Version}}", expected: "v25.4.13"},
+		{name: "WorkItemField", mapping: `Custom.X={{index .WorkItem.Fields "System.Title"}}`, expected: "Title"},
+		{name: "Integer", mapping: "Custom.X:number={{.Run.Id}}", expected: int64(42)},
+		{name: "Float", mapping: "Custom.X:number=4.5", expected: 4.5},
+		{name: "DateInString", mapping: "Custom.X={{.Run.FinishedDate}}", expected: "2025-04-13T10:30:00Z"},
+		{name: "Date", mapping: "Custom.X:date={{.Run.FinishedDate}}", expected: finishedDate},
+		{name: "FormattedDate", mapping: `Custom.X:date={{.Run.FinishedDate.Format "2006-01-02"}}`, expected: time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run("TestFieldMappingRender_"+test.name, func(t *testing.T) {
+			mapping, err := ParseFieldMapping(test.mapping)
+			assert.Nil(t, err)
+
+			value, err := mapping.Render(data)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestFieldMappingRender_InvalidNumber(t *testing.T) {
+	mapping, _ := ParseFieldMapping("Custom.X:number={{.Version}}")
+
+	_, err := mapping.Render(FieldTemplateData{Version: "25.4.13"})
+
+	assert.NotNil(t, err)
+}
+
+func TestApplyFieldMappings_ShouldSendAllFieldsInOneUpdate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	version, _ := ParseFieldMapping("Custom.TargetVersion={{.Version}}")
+	runId, _ := ParseFieldMapping("Custom.RunId:number={{.Run.Id}}")
+	mockRepo.On("UpdateWorkitemFields", "1", []model.OperationFields{
+		{Op: "add", Path: "/fields/Custom.TargetVersion", Value: "25.4.13"},
+		{Op: "add", Path: "/fields/Custom.RunId", Value: int64(42)},
+	}).Return(nil)
+
+	err := uc.applyFieldMappings([]model.WorkItem{createWorkItem(1, nil)}, []FieldMapping{version, runId}, FieldTemplateData{
+		Version: "25.4.13",
+		Run:     model.PipelineRuns{Id: 42},
+	})
+
+	assert.Nil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "UpdateWorkitemFields", 1)
+}
@@ -0,0 +1,124 @@
+package usescases
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/utils"
+)
+
+const (
+	AdoWorkItemTypeFieldName string = "System.WorkItemType"
+	AdoHierarchyReverseRel   string = "System.LinkTypes.Hierarchy-Reverse"
+
+	maxHierarchyDepth int = 10
+)
+
+// workItemCache keeps the work items read with their relations, which also carry their fields, so each one is read once
+type workItemCache struct {
+	repository AdoRepository
+	workItems  map[int]*model.WorkItem
+}
+
+func newWorkItemCache(repository AdoRepository) *workItemCache {
+	return &workItemCache{repository: repository, workItems: map[int]*model.WorkItem{}}
+}
+
+// get returns the work item with its relations, a work item that can't be read is read again on the next call
+func (c *workItemCache) get(workItemId int) (*model.WorkItem, error) {
+	if workItem, ok := c.workItems[workItemId]; ok {
+		return workItem, nil
+	}
+	workItem, err := c.repository.GetWorkItemWithRelations(strconv.Itoa(workItemId))
+	if err != nil {
+		return nil, err
+	}
+	c.workItems[workItemId] = workItem
+	return workItem, nil
+}
+
+// getParentWorkItems walks up the hierarchy of each work item until it finds an ancestor of type parentType
+// Ancestors already present in workItems or found twice are returned only once
+func (u *AdoUsesCases) getParentWorkItems(workItems []model.WorkItem, parentType string) []model.WorkItem {
+	known := make(map[int]bool, len(workItems))
+	for _, workItem := range workItems {
+		known[workItem.Id] = true
+	}
+
+	cache := newWorkItemCache(u.Repository)
+	parents := []model.WorkItem{}
+	for _, workItem := range workItems {
+		if workItem.Id == 0 || workItemType(workItem) == parentType {
+			continue
+		}
+		parent, ok := u.findAncestorOfType(cache, workItem.Id, parentType)
+		if !ok || known[parent.Id] {
+			continue
+		}
+		known[parent.Id] = true
+		parents = append(parents, *parent)
+	}
+	return parents
+}
+
+// findAncestorOfType reads each level of the hierarchy once, the ancestors shared by several work items are read from cache
+func (u *AdoUsesCases) findAncestorOfType(cache *workItemCache, workItemId int, parentType string) (*model.WorkItem, bool) {
+	visited := map[int]bool{workItemId: true}
+	currentId := workItemId
+
+	for depth := 0; depth < maxHierarchyDepth; depth++ {
+		current, err := cache.get(currentId)
+		if err != nil {
+			u.logWarn(err, currentId, "GetWorkItemWithRelations")
+			return nil, false
+		}
+		parentId, ok := relatedWorkItemId(current.Relations, AdoHierarchyReverseRel)
+		if !ok || visited[parentId] {
+			return nil, false
+		}
+		visited[parentId] = true
+
+		parent, err := cache.get(parentId)
+		if err != nil {
+			u.logWarn(err, parentId, "GetWorkItemWithRelations")
+			return nil, false
+		}
+		if workItemType(*parent) == parentType {
+			return parent, true
+		}
+		currentId = parentId
+	}
+	return nil, false
+}
+
+// relatedWorkItemId returns the id of the first work item linked with the relation rel
+func relatedWorkItemId(relations []model.WorkItemRelation, rel string) (int, bool) {
+	for _, relation := range relations {
+		if relation.Rel != rel {
+			continue
+		}
+		if id, err := workItemIdFromUrl(relation.Url); err == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func workItemIdFromUrl(url string) (int, error) {
+	return strconv.Atoi(url[strings.LastIndex(url, "/")+1:])
+}
+
+func workItemType(workItem model.WorkItem) string {
+	return utils.Coalesce[string](workItem.Fields[AdoWorkItemTypeFieldName], "")
+}
+
+func (u *AdoUsesCases) logWarn(err error, workItemId int, action string) {
+	if u.Logger == nil {
+		return
+	}
+	u.Logger.Warn().
+		Err(err).
+		Int("work-item-id", workItemId).
+		Msg(action)
+}
@@ -0,0 +1,98 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createRelation(rel string, id string) model.WorkItemRelation {
+	return model.WorkItemRelation{
+		Rel: rel,
+		Url: "https://dev.azure.com/org/project/_apis/wit/workItems/" + id,
+	}
+}
+
+func TestGetParentWorkItems_ShouldFindUserStoryOfTask(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	task := createWorkItem(1, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"})
+	mockRepo.On("GetWorkItemWithRelations", "1").Return(model.WorkItem{Id: 1, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "10")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "10").Return(createWorkItem(10, map[string]interface{}{AdoWorkItemTypeFieldName: "User Story"}), nil)
+
+	result := uc.getParentWorkItems([]model.WorkItem{task}, "User Story")
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, 10, result[0].Id)
+	mockRepo.AssertNotCalled(t, "GetWorkItem", mock.Anything)
+}
+
+func TestGetParentWorkItems_ShouldWalkUpUntilType(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	task := createWorkItem(1, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"})
+	mockRepo.On("GetWorkItemWithRelations", "1").Return(model.WorkItem{Id: 1, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "10")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "10").Return(model.WorkItem{Id: 10, Fields: map[string]interface{}{AdoWorkItemTypeFieldName: "User Story"}, Relations: []model.WorkItemRelation{
+		createRelation("System.LinkTypes.Related", "50"),
+		createRelation(AdoHierarchyReverseRel, "100"),
+	}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "100").Return(createWorkItem(100, map[string]interface{}{AdoWorkItemTypeFieldName: "Feature"}), nil)
+
+	result := uc.getParentWorkItems([]model.WorkItem{task}, "Feature")
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, 100, result[0].Id)
+	mockRepo.AssertNumberOfCalls(t, "GetWorkItemWithRelations", 3)
+}
+
+func TestGetParentWorkItems_ShouldDeduplicateParents(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"}),
+		createWorkItem(2, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"}),
+		createWorkItem(10, map[string]interface{}{AdoWorkItemTypeFieldName: "User Story"}),
+		createWorkItem(3, map[string]interface{}{AdoWorkItemTypeFieldName: "Bug"}),
+	}
+	mockRepo.On("GetWorkItemWithRelations", "1").Return(model.WorkItem{Id: 1, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "20")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "2").Return(model.WorkItem{Id: 2, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "20")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "3").Return(model.WorkItem{Id: 3, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "10")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "20").Return(createWorkItem(20, map[string]interface{}{AdoWorkItemTypeFieldName: "User Story"}), nil)
+	mockRepo.On("GetWorkItemWithRelations", "10").Return(createWorkItem(10, map[string]interface{}{AdoWorkItemTypeFieldName: "User Story"}), nil)
+
+	result := uc.getParentWorkItems(workItems, "User Story")
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, 20, result[0].Id)
+	// the parent 20 shared by 1 and 2 is read once
+	mockRepo.AssertNumberOfCalls(t, "GetWorkItemWithRelations", 5)
+}
+
+func TestGetParentWorkItems_ShouldIgnoreErrorsAndOrphans(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"}),
+		createWorkItem(2, map[string]interface{}{AdoWorkItemTypeFieldName: "Task"}),
+	}
+	mockRepo.On("GetWorkItemWithRelations", "1").Return(nil, errors.New("error"))
+	mockRepo.On("GetWorkItemWithRelations", "2").Return(model.WorkItem{Id: 2}, nil)
+
+	result := uc.getParentWorkItems(workItems, "User Story")
+
+	assert.Empty(t, result)
+}
+
+func TestWorkItemIdFromUrl(t *testing.T) {
+	id, err := workItemIdFromUrl("https://dev.azure.com/org/_apis/wit/workItems/1234")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1234, id)
+}
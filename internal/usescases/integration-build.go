@@ -0,0 +1,129 @@
+package usescases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/utils"
+)
+
+const (
+	IntegrationBuildSortNone string = "none"
+	IntegrationBuildSortAsc  string = "asc"
+	IntegrationBuildSortDesc string = "desc"
+
+	DefaultIntegrationBuildSeparator string = " | "
+	// AdoStringFieldMaxLength is the maximum length of a single line string field
+	AdoStringFieldMaxLength int = 255
+)
+
+// IntegrationBuildCodec reads and writes the history of versions stored in a single string field
+type IntegrationBuildCodec struct {
+	FieldName string
+	Separator string
+	// Sort is none to keep the insertion order, asc or desc to sort by version
+	Sort string
+	// MaxEntries is the maximum number of versions kept, 0 means no limit
+	MaxEntries int
+	// MaxLength is the maximum length of the stored value, 0 means no limit
+	MaxLength int
+}
+
+func DefaultIntegrationBuildCodec() IntegrationBuildCodec {
+	return IntegrationBuildCodec{
+		FieldName: AdoIntegrationBuildFieldName,
+		Separator: DefaultIntegrationBuildSeparator,
+		Sort:      IntegrationBuildSortNone,
+		MaxLength: AdoStringFieldMaxLength,
+	}
+}
+
+// Validate rejects an empty separator, which would join the versions so they can't be split again, and an unknown sort
+func (c IntegrationBuildCodec) Validate() error {
+	if c.Separator == "" {
+		return ErrInvalidIntegrationSeparator
+	}
+	if c.Sort != "" && c.Sort != IntegrationBuildSortNone && c.Sort != IntegrationBuildSortAsc && c.Sort != IntegrationBuildSortDesc {
+		return fmt.Errorf("%w: %s", ErrInvalidIntegrationSort, c.Sort)
+	}
+	return nil
+}
+
+func (c IntegrationBuildCodec) Path() string {
+	return "/fields/" + c.FieldName
+}
+
+// Decode splits the field value in versions, the separator is matched without its surrounding spaces
+func (c IntegrationBuildCodec) Decode(value string) []string {
+	separator := strings.TrimSpace(c.Separator)
+	var tokens []string
+	if separator == "" {
+		tokens = strings.Fields(value)
+	} else {
+		tokens = strings.Split(value, separator)
+	}
+
+	result := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// Encode joins the versions, dropping the oldest ones until the limits are respected
+// When sorted, the oldest versions are the lowest ones, otherwise the first inserted
+func (c IntegrationBuildCodec) Encode(versions []string) string {
+	versions = slices.Clone(versions)
+	if c.Sort == IntegrationBuildSortAsc || c.Sort == IntegrationBuildSortDesc {
+		slices.SortStableFunc(versions, func(a, b string) int {
+			return newVersion(a).isHigherThan(newVersion(b))
+		})
+	}
+
+	if c.MaxEntries > 0 && len(versions) > c.MaxEntries {
+		versions = versions[len(versions)-c.MaxEntries:]
+	}
+	for c.MaxLength > 0 && len(versions) > 1 && len(strings.Join(versions, c.Separator)) > c.MaxLength {
+		versions = versions[1:]
+	}
+
+	if c.Sort == IntegrationBuildSortDesc {
+		slices.Reverse(versions)
+	}
+	return strings.Join(versions, c.Separator)
+}
+
+// Add appends version to the field value if no entry is exactly equal to it
+// It returns false when the value doesn't change
+func (c IntegrationBuildCodec) Add(value string, version string) (string, bool) {
+	versions := c.Decode(value)
+	if !slices.Contains(versions, version) {
+		versions = append(versions, version)
+	}
+	result := c.Encode(versions)
+	return result, result != value
+}
+
+func (u *AdoUsesCases) updateAdoIntegrationBuild(workItems []model.WorkItem, version string, codec IntegrationBuildCodec) error {
+	var errMap error = nil
+	for _, workItem := range workItems {
+		if workItem.Id == 0 {
+			continue
+		}
+		val := utils.Coalesce[string](workItem.Fields[codec.FieldName], "")
+		concatenateVersion, changed := codec.Add(val, version)
+		if !changed {
+			continue
+		}
+		if err := u.updateFields(strconv.FormatInt(int64(workItem.Id), 10), concatenateVersion, codec.Path()); err != nil {
+			errMap = errors.Join(errMap, err)
+		}
+	}
+	return errMap
+}
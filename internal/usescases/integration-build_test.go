@@ -0,0 +1,163 @@
+package usescases
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIntegrationBuildCodecDecode(t *testing.T) {
+	codec := DefaultIntegrationBuildCodec()
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "Empty", value: "", expected: []string{}},
+		{name: "WithSpaces", value: "25.4.1 | 25.4.13", expected: []string{"25.4.1", "25.4.13"}},
+		{name: "WithoutSpaces", value: "25.4.1|25.4.13|", expected: []string{"25.4.1", "25.4.13"}},
+	}
+
+	for _, test := range tests {
+		t.Run("TestIntegrationBuildCodecDecode_"+test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, codec.Decode(test.value))
+		})
+	}
+}
+
+func TestIntegrationBuildCodecAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		codec    IntegrationBuildCodec
+		value    string
+		version  string
+		expected string
+		changed  bool
+	}{
+		{
+			name:     "EmptyField",
+			codec:    DefaultIntegrationBuildCodec(),
+			value:    "",
+			version:  "25.4.1",
+			expected: "25.4.1",
+			changed:  true,
+		},
+		{
+			name:     "PrefixOfExistingVersionIsAdded",
+			codec:    DefaultIntegrationBuildCodec(),
+			value:    "25.4.13",
+			version:  "25.4.1",
+			expected: "25.4.13 | 25.4.1",
+			changed:  true,
+		},
+		{
+			name:     "ExactVersionIsNotAdded",
+			codec:    DefaultIntegrationBuildCodec(),
+			value:    "25.4.1 | 25.4.13",
+			version:  "25.4.13",
+			expected: "25.4.1 | 25.4.13",
+			changed:  false,
+		},
+		{
+			name:     "LegacyFormatIsNormalized",
+			codec:    DefaultIntegrationBuildCodec(),
+			value:    "25.4.1|25.4.13",
+			version:  "25.4.13",
+			expected: "25.4.1 | 25.4.13",
+			changed:  true,
+		},
+		{
+			name:     "SortDesc",
+			codec:    IntegrationBuildCodec{Separator: ", ", Sort: IntegrationBuildSortDesc},
+			value:    "25.4.13, 25.4.1",
+			version:  "25.4.2",
+			expected: "25.4.13, 25.4.2, 25.4.1",
+			changed:  true,
+		},
+		{
+			name:     "SortAscWithMaxEntries",
+			codec:    IntegrationBuildCodec{Separator: ";", Sort: IntegrationBuildSortAsc, MaxEntries: 2},
+			value:    "25.4.13;25.4.1",
+			version:  "25.4.2",
+			expected: "25.4.2;25.4.13",
+			changed:  true,
+		},
+		{
+			name:     "MaxEntriesDropsFirstInserted",
+			codec:    IntegrationBuildCodec{Separator: " | ", MaxEntries: 2},
+			value:    "25.4.13 | 25.4.1",
+			version:  "25.4.2",
+			expected: "25.4.1 | 25.4.2",
+			changed:  true,
+		},
+		{
+			name:     "MaxLength",
+			codec:    IntegrationBuildCodec{Separator: " | ", MaxLength: 20},
+			value:    "25.4.1 | 25.4.2",
+			version:  "25.4.3",
+			expected: "25.4.2 | 25.4.3",
+			changed:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("TestIntegrationBuildCodecAdd_"+test.name, func(t *testing.T) {
+			result, changed := test.codec.Add(test.value, test.version)
+			assert.Equal(t, test.expected, result)
+			assert.Equal(t, test.changed, changed)
+		})
+	}
+}
+
+func TestIntegrationBuildCodecEncode_ShouldStayUnderFieldLimit(t *testing.T) {
+	codec := DefaultIntegrationBuildCodec()
+	versions := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		versions = append(versions, "25.4.13."+strings.Repeat("1", 3))
+	}
+
+	result := codec.Encode(versions)
+
+	assert.LessOrEqual(t, len(result), AdoStringFieldMaxLength)
+}
+
+func TestIntegrationBuildCodecValidate(t *testing.T) {
+	tests := map[string]struct {
+		separator string
+		sort      string
+		expected  error
+	}{
+		"default":         {separator: DefaultIntegrationBuildSeparator, sort: IntegrationBuildSortNone},
+		"space separator": {separator: " ", sort: IntegrationBuildSortDesc},
+		"empty separator": {separator: "", sort: IntegrationBuildSortNone, expected: ErrInvalidIntegrationSeparator},
+		"unknown sort":    {separator: DefaultIntegrationBuildSeparator, sort: "version", expected: ErrInvalidIntegrationSort},
+	}
+
+	for name, test := range tests {
+		t.Run("TestIntegrationBuildCodecValidate_"+name, func(t *testing.T) {
+			codec := IntegrationBuildCodec{Separator: test.separator, Sort: test.sort}
+
+			assert.ErrorIs(t, codec.Validate(), test.expected)
+		})
+	}
+}
+
+func TestUpdateAdoIntegrationBuild_ShouldSkipUnchangedWorkItems(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{AdoIntegrationBuildFieldName: "25.4.1 | 25.4.13"}),
+		createWorkItem(2, map[string]interface{}{AdoIntegrationBuildFieldName: "25.4.1"}),
+	}
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.updateAdoIntegrationBuild(workItems, "25.4.13", DefaultIntegrationBuildCodec())
+
+	assert.Nil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "UpdateWorkitemField", 1)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "2", model.OperationFields{Op: "add", Path: AdoIntegrationPath, Value: "25.4.1 | 25.4.13"})
+}
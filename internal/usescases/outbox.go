@@ -0,0 +1,118 @@
+package usescases
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+// Outbox keeps the release events until they are delivered
+type Outbox interface {
+	Save(entry model.OutboxEntry) error
+	List() ([]model.OutboxEntry, error)
+	Delete(id string) error
+}
+
+// storeInOutbox saves the event for sink before its delivery, a nil entry is returned without outbox
+func (u *AdoUsesCases) storeInOutbox(sink string, data model.N8nResult) *model.OutboxEntry {
+	if u.Outbox == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	entry := model.OutboxEntry{
+		Id:        fmt.Sprintf("%d-%s", now.UnixNano(), sink),
+		Sink:      sink,
+		CreatedAt: now,
+		Event:     data,
+	}
+	if err := u.Outbox.Save(entry); err != nil {
+		u.logOutboxError(err, entry.Id)
+		return nil
+	}
+	return &entry
+}
+
+// markOutboxEntry records the result of a delivery attempt
+// A delivered entry is removed, so the outbox only keeps the entries still to be delivered
+func (u *AdoUsesCases) markOutboxEntry(entry *model.OutboxEntry, err error) {
+	if entry == nil {
+		return
+	}
+	entry.Attempts++
+	if err == nil {
+		if err := u.Outbox.Delete(entry.Id); err != nil {
+			u.logOutboxError(err, entry.Id)
+		}
+		return
+	}
+	entry.LastError = err.Error()
+	if err := u.Outbox.Save(*entry); err != nil {
+		u.logOutboxError(err, entry.Id)
+	}
+}
+
+func (u *AdoUsesCases) ListOutbox() ([]model.OutboxEntry, error) {
+	if u.Outbox == nil {
+		return []model.OutboxEntry{}, nil
+	}
+	return u.Outbox.List()
+}
+
+// ReplayOutbox delivers again every pending entry whose sink is configured
+func (u *AdoUsesCases) ReplayOutbox() ([]NotificationResult, error) {
+	entries, err := u.ListOutbox()
+	if err != nil {
+		return []NotificationResult{}, err
+	}
+
+	notifiers := make(map[string]Notifier, len(u.Notifiers))
+	for _, notifier := range u.Notifiers {
+		notifiers[notifier.Name()] = notifier
+	}
+
+	results := []NotificationResult{}
+	for _, entry := range entries {
+		result := NotificationResult{Sink: entry.Sink}
+		if notifier, ok := notifiers[entry.Sink]; ok {
+			result.Err = notifier.Notify(entry.Event)
+			u.markOutboxEntry(&entry, result.Err)
+		} else {
+			result.Err = fmt.Errorf("%w: %s", ErrSinkNotConfigured, entry.Sink)
+		}
+		u.logNotificationResult(result)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// PurgeOutbox removes every entry, the notifications are not delivered anymore
+// It returns the number of removed entries
+func (u *AdoUsesCases) PurgeOutbox() (int, error) {
+	entries, err := u.ListOutbox()
+	if err != nil {
+		return 0, err
+	}
+
+	var errMap error = nil
+	count := 0
+	for _, entry := range entries {
+		if err := u.Outbox.Delete(entry.Id); err != nil {
+			errMap = errors.Join(errMap, err)
+			continue
+		}
+		count++
+	}
+	return count, errMap
+}
+
+func (u *AdoUsesCases) logOutboxError(err error, id string) {
+	if u.Logger == nil {
+		return
+	}
+	u.Logger.Error().
+		Err(err).
+		Str("outbox-id", id).
+		Msg("Outbox")
+}
@@ -0,0 +1,99 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MemoryOutbox is an in memory Outbox
+type MemoryOutbox struct {
+	entries map[string]model.OutboxEntry
+}
+
+func NewMemoryOutbox(entries ...model.OutboxEntry) *MemoryOutbox {
+	outbox := &MemoryOutbox{entries: map[string]model.OutboxEntry{}}
+	for _, entry := range entries {
+		outbox.entries[entry.Id] = entry
+	}
+	return outbox
+}
+
+func (m *MemoryOutbox) Save(entry model.OutboxEntry) error {
+	m.entries[entry.Id] = entry
+	return nil
+}
+
+func (m *MemoryOutbox) List() ([]model.OutboxEntry, error) {
+	result := make([]model.OutboxEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (m *MemoryOutbox) Delete(id string) error {
+	delete(m.entries, id)
+	return nil
+}
+
+func TestNotify_ShouldKeepEventsInOutbox(t *testing.T) {
+	failing := new(MockN8N)
+	failing.On("Notify", mock.Anything).Return(errors.New("n8n down"))
+	outbox := NewMemoryOutbox()
+	usecase := AdoUsesCases{Notifiers: []Notifier{failing}, Outbox: outbox}
+
+	usecase.notify(model.N8nResult{Version: "25.4.13"})
+
+	entries, _ := outbox.List()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "n8n", entries[0].Sink)
+	assert.Equal(t, "25.4.13", entries[0].Event.Version)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, "n8n down", entries[0].LastError)
+}
+
+func TestReplayOutbox_ShouldDeliverPendingEntries(t *testing.T) {
+	outbox := NewMemoryOutbox(
+		model.OutboxEntry{Id: "1-n8n", Sink: "n8n", Attempts: 1, LastError: "n8n down", Event: model.N8nResult{Version: "25.4.1"}},
+		model.OutboxEntry{Id: "3-slack", Sink: "slack", Attempts: 1},
+	)
+	notifier := new(MockN8N)
+	notifier.On("Notify", model.N8nResult{Version: "25.4.1"}).Return(nil)
+	usecase := AdoUsesCases{Notifiers: []Notifier{notifier}, Outbox: outbox}
+
+	results, err := usecase.ReplayOutbox()
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+	assert.NotContains(t, outbox.entries, "1-n8n")
+	assert.Equal(t, 1, outbox.entries["3-slack"].Attempts)
+}
+
+func TestNotify_ShouldRemoveDeliveredEntries(t *testing.T) {
+	notifier := new(MockN8N)
+	notifier.On("Notify", mock.Anything).Return(nil)
+	outbox := NewMemoryOutbox()
+	usecase := AdoUsesCases{Notifiers: []Notifier{notifier}, Outbox: outbox}
+
+	usecase.notify(model.N8nResult{Version: "25.4.13"})
+
+	assert.Empty(t, outbox.entries)
+}
+
+func TestPurgeOutbox(t *testing.T) {
+	outbox := NewMemoryOutbox(
+		model.OutboxEntry{Id: "1-n8n", Sink: "n8n"},
+		model.OutboxEntry{Id: "2-n8n", Sink: "n8n", Attempts: 1},
+	)
+
+	count, err := (&AdoUsesCases{Outbox: outbox}).PurgeOutbox()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Empty(t, outbox.entries)
+}
@@ -0,0 +1,139 @@
+package usescases
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
+)
+
+// PathFilter keeps the commits changing a path matched by an include glob and by no exclude glob
+// Every path not excluded is kept when there is no include glob
+type PathFilter struct {
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+}
+
+// ParsePathFilter parses globs like 'services/api/**' or '!**/*.md', a leading ! excludes the paths
+func ParsePathFilter(globs []string) (*PathFilter, error) {
+	filter := &PathFilter{}
+	for _, glob := range globs {
+		exclude := strings.HasPrefix(glob, "!")
+		expression, err := globRegexp(strings.TrimPrefix(glob, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			filter.Exclude = append(filter.Exclude, expression)
+		} else {
+			filter.Include = append(filter.Include, expression)
+		}
+	}
+	return filter, nil
+}
+
+// Match tells if the path, relative to the root of the repository, is kept by the filter
+func (f PathFilter) Match(path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	matchPath := func(expression *regexp.Regexp) bool {
+		return expression.MatchString(path)
+	}
+	return (len(f.Include) == 0 || slices.ContainsFunc(f.Include, matchPath)) && !slices.ContainsFunc(f.Exclude, matchPath)
+}
+
+// globRegexp compiles a glob matched from the root of the repository
+// ** matches any number of directories, * and ? don't match a /, a glob matching a directory matches its files
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	glob = strings.Trim(glob, "/")
+	if glob == "" {
+		return nil, fmt.Errorf("%w: empty glob", ErrInvalidPathFilter)
+	}
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expression.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expression.WriteString("[^/]*")
+		case glob[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	expression.WriteString("(?:/.*)?$")
+	return regexp.Compile(expression.String())
+}
+
+// getPathWorkItemIds returns the ids of the work items linked to, or mentioned by, the commits since the baseline builds[1]
+// that change a path kept by the filter
+// The commits are read from the Azure Repos repository of the pipeline, an unreadable range is an error so no work item gets a wrong version
+// The bare #1234 mentions are only read when hashMentions is true, like the discovery
+func (u *AdoUsesCases) getPathWorkItemIds(builds []model.PipelineRuns, repositoryId string, filter PathFilter, hashMentions bool) (map[int]bool, error) {
+	run, baseline := builds[0], builds[1]
+	id := aliasRepositoryId(SelfRepositoryAlias, selfRepository(run), repositoryId)
+	from, to := sourceVersion(baseline), sourceVersion(run)
+	if id == "" || from == "" || to == "" {
+		return nil, ErrPathFilterUnavailable
+	}
+
+	result := map[int]bool{}
+	if from == to {
+		return result, nil
+	}
+	commits, err := u.Repository.GetCommitsBetween(id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range commits {
+		changes, err := u.Repository.GetCommitChanges(id, commit.CommitId)
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %w", commit.CommitId, err)
+		}
+		if !slices.ContainsFunc(changes, func(change model.GitChange) bool { return filter.Match(change.Item.Path) }) {
+			continue
+		}
+		for _, reference := range commit.WorkItems {
+			if workItemId, err := strconv.Atoi(reference.Id); err == nil {
+				result[workItemId] = true
+			}
+		}
+		for _, workItemId := range workItemIdsInMessage(commit.Comment, hashMentions) {
+			result[workItemId] = true
+		}
+	}
+	return result, nil
+}
+
+// keepWorkItems returns the work items whose id is in ids
+func keepWorkItems(workItems []model.WorkItem, ids map[int]bool) []model.WorkItem {
+	return queryslice.Filter(workItems, func(workItem model.WorkItem) bool {
+		return ids[workItem.Id]
+	})
+}
+
+// keepPathRepositories removes the pipeline's own repository from the work items outside of ids,
+// so the path filter isn't bypassed by the commits of the self repository, the other repositories are kept
+func keepPathRepositories(repositories map[int][]string, ids map[int]bool) map[int][]string {
+	result := make(map[int][]string, len(repositories))
+	for workItemId, aliases := range repositories {
+		if !ids[workItemId] {
+			aliases = slices.DeleteFunc(slices.Clone(aliases), func(alias string) bool {
+				return alias == SelfRepositoryAlias
+			})
+		}
+		if len(aliases) > 0 {
+			result[workItemId] = aliases
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPathFilterMatch(t *testing.T) {
+	tests := map[string]struct {
+		globs    []string
+		path     string
+		expected bool
+	}{
+		"directory":               {globs: []string{"services/api"}, path: "/services/api/main.go", expected: true},
+		"other directory":         {globs: []string{"services/api"}, path: "/services/api-gateway/main.go", expected: false},
+		"double star":             {globs: []string{"services/api/**"}, path: "/services/api/handlers/user.go", expected: true},
+		"star doesn't cross /":    {globs: []string{"services/*.go"}, path: "/services/api/main.go", expected: false},
+		"any directory":           {globs: []string{"**/*.proto"}, path: "/libs/proto/user.proto", expected: true},
+		"root with any directory": {globs: []string{"**/*.proto"}, path: "/user.proto", expected: true},
+		"question mark":           {globs: []string{"services/v?/**"}, path: "/services/v2/main.go", expected: true},
+		"excluded":                {globs: []string{"services/api/**", "!**/*.md"}, path: "/services/api/README.md", expected: false},
+		"only excludes":           {globs: []string{"!docs"}, path: "/services/api/main.go", expected: true},
+		"only excludes, excluded": {globs: []string{"!docs"}, path: "/docs/index.md", expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run("TestPathFilterMatch_"+name, func(t *testing.T) {
+			filter, err := ParsePathFilter(test.globs)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, filter.Match(test.path))
+		})
+	}
+}
+
+func TestParsePathFilter_ShouldReturnError_OnEmptyGlob(t *testing.T) {
+	_, err := ParsePathFilter([]string{"services/api", "!"})
+
+	assert.ErrorIs(t, err, ErrInvalidPathFilter)
+}
+
+func TestGetPathWorkItemIds(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "", 2, "commit-3"),
+		createRunOnCommit("refs/heads/main", "", 1, "commit-1"),
+	}
+	filter, _ := ParsePathFilter([]string{"services/api", "!**/*.md"})
+	mockRepo.On("GetCommitsBetween", "repo-id", "commit-1", "commit-3").Return([]model.GitCommitRef{
+		{CommitId: "commit-3", Comment: "Fix login AB#4", WorkItems: []model.ResourceRef{{Id: "1"}}},
+		{CommitId: "commit-2", WorkItems: []model.ResourceRef{{Id: "2"}}},
+		{CommitId: "commit-2b", WorkItems: []model.ResourceRef{{Id: "3"}}},
+	}, nil)
+	mockRepo.On("GetCommitChanges", "repo-id", "commit-3").Return([]model.GitChange{{Item: model.GitItem{Path: "/services/api/login.go"}}}, nil)
+	mockRepo.On("GetCommitChanges", "repo-id", "commit-2").Return([]model.GitChange{{Item: model.GitItem{Path: "/services/web/index.ts"}}}, nil)
+	mockRepo.On("GetCommitChanges", "repo-id", "commit-2b").Return([]model.GitChange{{Item: model.GitItem{Path: "/services/api/README.md"}}}, nil)
+
+	ids, err := uc.getPathWorkItemIds(builds, "repo-id", *filter, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]bool{1: true, 4: true}, ids)
+}
+
+func TestGetPathWorkItemIds_ShouldReturnError_WithoutSourceCommit(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "", 2),
+		createPipelineRun("refs/heads/main", "", 1),
+	}
+
+	_, err := uc.getPathWorkItemIds(builds, "repo-id", PathFilter{}, false)
+
+	assert.ErrorIs(t, err, ErrPathFilterUnavailable)
+}
+
+func TestUpdateFieldsForRuns_ShouldKeepWorkItemsOfFilteredPaths(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, "commit-2"),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "commit-1"),
+	}
+	filter, _ := ParsePathFilter([]string{"services/api"})
+	mockRepo.On("GetBuildWorkItem", 1, 2).Return([]model.BuildWorkItems{{Id: "1"}, {Id: "2"}}, nil)
+	mockRepo.On("GetBuildChanges", 1, 2).Return([]model.BuildChanges{}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, map[string]interface{}{"Custom": ""}), nil)
+	mockRepo.On("GetWorkItem", "2").Return(createWorkItem(2, map[string]interface{}{"Custom": ""}), nil)
+	mockRepo.On("GetCommitsBetween", "repo-id", "commit-1", "commit-2").Return([]model.GitCommitRef{
+		{CommitId: "commit-2", WorkItems: []model.ResourceRef{{Id: "1"}}},
+	}, nil)
+	mockRepo.On("GetCommitChanges", "repo-id", "commit-2").Return([]model.GitChange{{Item: model.GitItem{Path: "/services/api/main.go"}}}, nil)
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.updateFieldsForRuns(builds, UpdateFieldsParams{RepositoryId: "repo-id", FieldName: "/fields/Custom", PathFilter: filter})
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "1", model.OperationFields{Op: "add", Path: "/fields/Custom", Value: "25.4.2"})
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemField", "2", mock.Anything)
+}
+
+func TestUpdateFieldsForRuns_ShouldReturnError_OnCommitChanges(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, "commit-2"),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "commit-1"),
+	}
+	mockRepo.On("GetBuildWorkItem", 1, 2).Return([]model.BuildWorkItems{}, nil)
+	mockRepo.On("GetBuildChanges", 1, 2).Return([]model.BuildChanges{}, nil)
+	mockRepo.On("GetCommitsBetween", "repo-id", "commit-1", "commit-2").Return([]model.GitCommitRef{{CommitId: "commit-2"}}, nil)
+	mockRepo.On("GetCommitChanges", "repo-id", "commit-2").Return(nil, errors.New("error"))
+
+	err := uc.updateFieldsForRuns(builds, UpdateFieldsParams{RepositoryId: "repo-id", FieldName: "/fields/Custom", PathFilter: &PathFilter{}})
+
+	assert.EqualError(t, err, "commit commit-2: error")
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemField", mock.Anything, mock.Anything)
+}
+
+func TestKeepPathRepositories(t *testing.T) {
+	repositories := map[int][]string{1: {"self"}, 2: {"self", "tools"}, 3: {"tools"}, 4: {"self"}}
+
+	result := keepPathRepositories(repositories, map[int]bool{4: true})
+
+	assert.Equal(t, map[int][]string{2: {"tools"}, 3: {"tools"}, 4: {"self"}}, result)
+}
@@ -0,0 +1,69 @@
+package usescases
+
+import (
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/utils"
+)
+
+const (
+	AdoAreaPathFieldName string = "System.AreaPath"
+)
+
+// newReleaseEvent builds the payload sent to the notifiers for the run builds[0] against its baseline builds[1]
+// Each work item is tagged with the aliases of the repositories it came from
+func newReleaseEvent(workItems []model.WorkItem, commits []model.BuildChanges, repositories map[int][]string, builds []model.PipelineRuns, param UpdateFieldsParams, codec IntegrationBuildCodec) model.N8nResult {
+	run, baseline := builds[0], builds[1]
+
+	data := WorkItemToN8NResult(workItems, codec, param.PayloadFields)
+	data.Version = run.Name
+	data.SourceBranch = param.runBranch(run)
+	data.PipelineId = param.PipelineId
+	data.Pipeline = run.Pipeline
+	data.RepositoryId = param.RepositoryId
+	data.RunId = run.Id
+	data.RunUrl = run.Links.Web.Href
+	data.BaselineRunId = baseline.Id
+	data.BaselineRunUrl = baseline.Links.Web.Href
+	if from, to := sourceVersion(baseline), sourceVersion(run); from != "" || to != "" {
+		data.CommitRange = &model.CommitRange{From: from, To: to}
+	}
+	data.Commits = commitsToN8nCommits(commits)
+	for index := range data.WorkItems {
+		data.WorkItems[index].Repositories = repositories[data.WorkItems[index].Id]
+	}
+	return data
+}
+
+// sourceVersion returns the commit built by the run
+func sourceVersion(run model.PipelineRuns) string {
+	return selfRepository(run).Version
+}
+
+// workItemWebUrl turns the REST url of a work item into the url of its page
+func workItemWebUrl(workItem model.WorkItem) string {
+	return strings.Replace(workItem.Url, "/_apis/wit/workItems/", "/_workitems/edit/", 1)
+}
+
+func assigneeName(workItem model.WorkItem) string {
+	assignee, ok := workItem.Fields[AdoAssignedToFieldName].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	return utils.Coalesce[string](assignee["displayName"], "")
+}
+
+// mapPayloadFields copies the work item fields to their payload key, the missing fields are skipped
+func mapPayloadFields(workItem model.WorkItem, payloadFields map[string]string) map[string]interface{} {
+	if len(payloadFields) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(payloadFields))
+	for key, fieldName := range payloadFields {
+		if value, ok := workItem.Fields[fieldName]; ok {
+			result[key] = value
+		}
+	}
+	return result
+}
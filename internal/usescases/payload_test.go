@@ -0,0 +1,79 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReleaseEvent(t *testing.T) {
+	run := createRunOnCommit("refs/heads/main", "25.4.13", 42, "def456")
+	run.Links.Web.Href = "https://dev.azure.com/org/project/_build/results?buildId=42"
+	run.Pipeline = &model.PipelineReference{Id: 7, Name: "product-ci"}
+	baseline := createRunOnCommit("refs/heads/main", "25.4.12", 40, "abc123")
+	baseline.Links.Web.Href = "https://dev.azure.com/org/project/_build/results?buildId=40"
+
+	workItems := []model.WorkItem{
+		{
+			Id:  1,
+			Url: "https://dev.azure.com/org/project/_apis/wit/workItems/1",
+			Fields: map[string]interface{}{
+				AdoTitleFieldName:                "Title",
+				AdoWorkItemTypeFieldName:         "User Story",
+				AdoStateFieldName:                "Active",
+				AdoAreaPathFieldName:             "project\\team",
+				AdoAssignedToFieldName:           map[string]interface{}{"displayName": "Dev", "id": "a1b2"},
+				"Microsoft.VSTS.Common.Priority": float64(2),
+			},
+		},
+	}
+
+	data := newReleaseEvent(workItems, nil, nil, []model.PipelineRuns{run, baseline}, UpdateFieldsParams{
+		PipelineId:    7,
+		RepositoryId:  "repo-id",
+		BranchName:    "main",
+		PayloadFields: map[string]string{"priority": "Microsoft.VSTS.Common.Priority", "missing": "Custom.Missing"},
+	}, DefaultIntegrationBuildCodec())
+
+	assert.Equal(t, "25.4.13", data.Version)
+	assert.Equal(t, "main", data.SourceBranch)
+	assert.Equal(t, 7, data.PipelineId)
+	assert.Equal(t, "product-ci", data.Pipeline.Name)
+	assert.Equal(t, "repo-id", data.RepositoryId)
+	assert.Equal(t, 42, data.RunId)
+	assert.Equal(t, run.Links.Web.Href, data.RunUrl)
+	assert.Equal(t, 40, data.BaselineRunId)
+	assert.Equal(t, baseline.Links.Web.Href, data.BaselineRunUrl)
+	assert.Equal(t, &model.CommitRange{From: "abc123", To: "def456"}, data.CommitRange)
+	assert.Equal(t, model.N8NWorkItems{
+		Id:               1,
+		Title:            "Title",
+		Type:             "User Story",
+		State:            "Active",
+		AssignedTo:       "Dev",
+		AreaPath:         "project\\team",
+		Url:              "https://dev.azure.com/org/project/_workitems/edit/1",
+		Tags:             []string{},
+		IntegrationBuild: []string{},
+		Fields:           map[string]interface{}{"priority": float64(2)},
+	}, data.WorkItems[0])
+}
+
+func TestNewReleaseEvent_WithoutCommits(t *testing.T) {
+	data := newReleaseEvent([]model.WorkItem{}, nil, nil, []model.PipelineRuns{{Id: 2}, {Id: 1}}, UpdateFieldsParams{}, DefaultIntegrationBuildCodec())
+
+	assert.Nil(t, data.CommitRange)
+	assert.Empty(t, data.WorkItems)
+}
+
+func TestNewReleaseEvent_ShouldSendBranchOfRun_WithGlob(t *testing.T) {
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/release/25.4", "25.4.2", 2),
+		createPipelineRun("refs/heads/release/25.4", "25.4.1", 1),
+	}
+
+	data := newReleaseEvent([]model.WorkItem{}, nil, nil, builds, UpdateFieldsParams{BranchName: "release/*", BranchMatch: BranchMatchGlob}, DefaultIntegrationBuildCodec())
+
+	assert.Equal(t, "release/25.4", data.SourceBranch)
+}
@@ -0,0 +1,69 @@
+package usescases
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	WorkItemSourceBuild        string = "build"
+	WorkItemSourcePullRequests string = "pull-requests"
+)
+
+// getPullRequestWorkItems returns the work items linked to the pull requests completed into the ref of builds[0] since its baseline builds[1]
+// The pull requests are matched on their merge commit when the commits of the range are known, on their closing date otherwise
+func (u *AdoUsesCases) getPullRequestWorkItems(builds []model.PipelineRuns, repositoryId string, commits []model.BuildChanges) ([]model.WorkItem, error) {
+	run, baseline := builds[0], builds[1]
+	refName := runRefName(run)
+	if refName == "" {
+		return []model.WorkItem{}, nil
+	}
+	maxTime := run.FinishedDate
+	if maxTime.IsZero() {
+		maxTime = time.Now()
+	}
+
+	pullRequests, err := u.Repository.GetPullRequests(repositoryId, refName, baseline.CreatedDate, maxTime)
+	if err != nil {
+		return []model.WorkItem{}, err
+	}
+
+	commitIds := make([]string, len(commits))
+	for index, commit := range commits {
+		commitIds[index] = commit.Id
+	}
+
+	known := map[string]bool{}
+	references := []model.BuildWorkItems{}
+	for _, pullRequest := range pullRequests {
+		if len(commitIds) > 0 && !slices.Contains(commitIds, pullRequest.LastMergeCommit.CommitId) {
+			continue
+		}
+		if len(commitIds) == 0 && pullRequest.ClosedDate.After(run.CreatedDate) {
+			continue
+		}
+		workItems, err := u.Repository.GetPullRequestWorkItems(repositoryId, pullRequest.PullRequestId)
+		if err != nil {
+			return []model.WorkItem{}, err
+		}
+		for _, workItem := range workItems {
+			if !known[workItem.Id] {
+				known[workItem.Id] = true
+				references = append(references, workItem)
+			}
+		}
+	}
+	return u.fetchWorkItems(references), nil
+}
+
+// appendMissingWorkItems appends the work items of others that are not already in workItems
+func appendMissingWorkItems(workItems []model.WorkItem, others []model.WorkItem) []model.WorkItem {
+	for _, other := range others {
+		if !slices.ContainsFunc(workItems, func(workItem model.WorkItem) bool { return workItem.Id == other.Id }) {
+			workItems = append(workItems, other)
+		}
+	}
+	return workItems
+}
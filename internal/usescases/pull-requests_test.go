@@ -0,0 +1,93 @@
+package usescases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createPullRequest(id int, mergeCommit string, closedDate time.Time) model.PullRequest {
+	pullRequest := model.PullRequest{PullRequestId: id, ClosedDate: closedDate}
+	pullRequest.LastMergeCommit.CommitId = mergeCommit
+	return pullRequest
+}
+
+func createRunsInRange() []model.PipelineRuns {
+	run := createPipelineRun("refs/heads/main", "25.4.13", 42)
+	run.CreatedDate = time.Date(2025, 4, 13, 10, 0, 0, 0, time.UTC)
+	run.FinishedDate = time.Date(2025, 4, 13, 10, 30, 0, 0, time.UTC)
+	baseline := createPipelineRun("refs/heads/main", "25.4.12", 40)
+	baseline.CreatedDate = time.Date(2025, 4, 12, 10, 0, 0, 0, time.UTC)
+	return []model.PipelineRuns{run, baseline}
+}
+
+func TestGetPullRequestWorkItems_ShouldMatchMergeCommits(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	builds := createRunsInRange()
+
+	mockRepo.On("GetPullRequests", "repo-id", "refs/heads/main", builds[1].CreatedDate, builds[0].FinishedDate).Return([]model.PullRequest{
+		createPullRequest(7, "abc123", builds[0].CreatedDate),
+		createPullRequest(8, "zzz999", builds[0].CreatedDate),
+		createPullRequest(9, "def456", builds[0].CreatedDate),
+	}, nil)
+	mockRepo.On("GetPullRequestWorkItems", "repo-id", 7).Return([]model.BuildWorkItems{{Id: "1"}, {Id: "2"}}, nil)
+	mockRepo.On("GetPullRequestWorkItems", "repo-id", 9).Return([]model.BuildWorkItems{{Id: "2"}}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, nil), nil)
+	mockRepo.On("GetWorkItem", "2").Return(createWorkItem(2, nil), nil)
+
+	result, err := uc.getPullRequestWorkItems(builds, "repo-id", []model.BuildChanges{{Id: "abc123"}, {Id: "def456"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.WorkItem{createWorkItem(1, nil), createWorkItem(2, nil)}, result)
+	mockRepo.AssertNotCalled(t, "GetPullRequestWorkItems", "repo-id", 8)
+}
+
+func TestGetPullRequestWorkItems_WithoutCommitsShouldMatchClosingDate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	builds := createRunsInRange()
+
+	mockRepo.On("GetPullRequests", "repo-id", "refs/heads/main", mock.Anything, mock.Anything).Return([]model.PullRequest{
+		createPullRequest(7, "abc123", builds[0].CreatedDate.Add(-time.Hour)),
+		createPullRequest(8, "def456", builds[0].CreatedDate.Add(time.Minute)),
+	}, nil)
+	mockRepo.On("GetPullRequestWorkItems", "repo-id", 7).Return([]model.BuildWorkItems{{Id: "1"}}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, nil), nil)
+
+	result, err := uc.getPullRequestWorkItems(builds, "repo-id", []model.BuildChanges{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.WorkItem{createWorkItem(1, nil)}, result)
+	mockRepo.AssertNotCalled(t, "GetPullRequestWorkItems", "repo-id", 8)
+}
+
+func TestAppendMissingWorkItems(t *testing.T) {
+	result := appendMissingWorkItems(
+		[]model.WorkItem{createWorkItem(1, nil), createWorkItem(2, nil)},
+		[]model.WorkItem{createWorkItem(2, nil), createWorkItem(3, nil)},
+	)
+
+	assert.Equal(t, []model.WorkItem{createWorkItem(1, nil), createWorkItem(2, nil), createWorkItem(3, nil)}, result)
+}
+
+func TestUpdateFieldsByLastRuns_WithInvalidWorkItemSource(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+
+	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{FieldName: "Custom", WorkItemSources: []string{"commits"}})
+
+	assert.ErrorIs(t, err, ErrInvalidWorkItemSource)
+}
+
+func TestUpdateFieldsForRuns_ShouldReturnError_WithPullRequestsWithoutRepository(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	err := uc.updateFieldsForRuns(createRunsInRange(), UpdateFieldsParams{FieldName: "/fields/Custom", WorkItemSources: []string{WorkItemSourcePullRequests}})
+
+	assert.ErrorIs(t, err, ErrUnknownRepository)
+	mockRepo.AssertNotCalled(t, "GetPullRequests", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
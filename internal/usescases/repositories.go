@@ -0,0 +1,106 @@
+package usescases
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	SelfRepositoryAlias string = "self"
+)
+
+// selfRepository returns the repository of the pipeline, empty when the run doesn't tell it
+func selfRepository(run model.PipelineRuns) model.RepositoryResource {
+	if run.Resources == nil {
+		return model.RepositoryResource{}
+	}
+	return run.Resources.Repositories[SelfRepositoryAlias]
+}
+
+// isMultiRepository tells if the run checks out other repositories than the one of the pipeline
+func isMultiRepository(run model.PipelineRuns) bool {
+	return run.Resources != nil && len(run.Resources.Repositories) > 1
+}
+
+// getRepositoryWorkItems returns, by work item id, the aliases of the repositories whose commits since the baseline builds[1] link it
+// Only the Azure Repos repositories checked out by both runs are read, a repository that can't be read is logged and skipped
+func (u *AdoUsesCases) getRepositoryWorkItems(builds []model.PipelineRuns, repositoryId string) map[int][]string {
+	run, baseline := builds[0], builds[1]
+	result := map[int][]string{}
+	if run.Resources == nil || baseline.Resources == nil {
+		return result
+	}
+
+	aliases := make([]string, 0, len(run.Resources.Repositories))
+	for alias := range run.Resources.Repositories {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+
+	for _, alias := range aliases {
+		to, from := run.Resources.Repositories[alias], baseline.Resources.Repositories[alias]
+		id := aliasRepositoryId(alias, to, repositoryId)
+		if id == "" || to.Version == "" || from.Version == "" || to.Version == from.Version {
+			continue
+		}
+		commits, err := u.Repository.GetCommitsBetween(id, from.Version, to.Version)
+		if err != nil {
+			if u.Logger != nil {
+				u.Logger.Warn().Err(err).Str("repository", alias).Msg("GetCommitsBetween")
+			}
+			continue
+		}
+		for _, commit := range commits {
+			for _, reference := range commit.WorkItems {
+				workItemId, err := strconv.Atoi(reference.Id)
+				if err == nil && !slices.Contains(result[workItemId], alias) {
+					result[workItemId] = append(result[workItemId], alias)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// aliasRepositoryId returns the id of the Azure Repos repository of the alias, empty for the other hosts
+func aliasRepositoryId(alias string, resource model.RepositoryResource, repositoryId string) string {
+	if resource.Repository != nil && resource.Repository.Type != "" && resource.Repository.Type != RepositoryTypeAzureReposGit {
+		return ""
+	}
+	if resource.Repository != nil && resource.Repository.Id != "" {
+		return resource.Repository.Id
+	}
+	if alias == SelfRepositoryAlias {
+		return repositoryId
+	}
+	return ""
+}
+
+// appendRepositoryWorkItems adds the work items found in the repositories but not linked to the build
+func (u *AdoUsesCases) appendRepositoryWorkItems(workItems []model.WorkItem, repositories map[int][]string) []model.WorkItem {
+	ids := make([]int, 0, len(repositories))
+	for workItemId := range repositories {
+		ids = append(ids, workItemId)
+	}
+	return u.appendWorkItemsById(workItems, ids)
+}
+
+// appendWorkItemsById reads and adds the work items of ids missing from workItems, by increasing id
+// A work item that can't be read is skipped
+func (u *AdoUsesCases) appendWorkItemsById(workItems []model.WorkItem, ids []int) []model.WorkItem {
+	slices.Sort(ids)
+	references := []model.BuildWorkItems{}
+	for _, workItemId := range slices.Compact(ids) {
+		if !slices.ContainsFunc(workItems, func(workItem model.WorkItem) bool { return workItem.Id == workItemId }) {
+			references = append(references, model.BuildWorkItems{Id: strconv.Itoa(workItemId)})
+		}
+	}
+	for _, workItem := range u.fetchWorkItems(references) {
+		if workItem.Id != 0 {
+			workItems = append(workItems, workItem)
+		}
+	}
+	return workItems
+}
@@ -0,0 +1,82 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func createMultiRepositoryRun(id int, selfVersion, toolsVersion, githubVersion string) model.PipelineRuns {
+	run := createRunOnCommit("refs/heads/main", "", id, selfVersion)
+	run.Resources.Repositories["tools"] = model.RepositoryResource{
+		RefName:    "refs/heads/main",
+		Version:    toolsVersion,
+		Repository: &model.RunRepository{Id: "tools-id", Type: RepositoryTypeAzureReposGit},
+	}
+	run.Resources.Repositories["docs"] = model.RepositoryResource{
+		RefName:    "refs/heads/main",
+		Version:    githubVersion,
+		Repository: &model.RunRepository{Type: RepositoryTypeGitHub, FullName: "org/docs"},
+	}
+	return run
+}
+
+func TestGetRepositoryWorkItems(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createMultiRepositoryRun(2, "self-2", "tools-2", "docs-2"),
+		createMultiRepositoryRun(1, "self-1", "tools-1", "docs-1"),
+	}
+	mockRepo.On("GetCommitsBetween", "repo-id", "self-1", "self-2").Return([]model.GitCommitRef{
+		{CommitId: "self-2", WorkItems: []model.ResourceRef{{Id: "1"}, {Id: "2"}}},
+	}, nil)
+	mockRepo.On("GetCommitsBetween", "tools-id", "tools-1", "tools-2").Return([]model.GitCommitRef{
+		{CommitId: "tools-2", WorkItems: []model.ResourceRef{{Id: "2"}}},
+		{CommitId: "tools-1b", WorkItems: []model.ResourceRef{{Id: "3"}, {Id: "2"}}},
+	}, nil)
+
+	repositories := uc.getRepositoryWorkItems(builds, "repo-id")
+
+	assert.Equal(t, map[int][]string{1: {"self"}, 2: {"self", "tools"}, 3: {"tools"}}, repositories)
+	mockRepo.AssertNumberOfCalls(t, "GetCommitsBetween", 2)
+}
+
+func TestGetRepositoryWorkItems_ShouldSkipUnchangedAndFailedRepositories(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createMultiRepositoryRun(2, "self-1", "tools-2", "docs-2"),
+		createMultiRepositoryRun(1, "self-1", "tools-1", "docs-1"),
+	}
+	mockRepo.On("GetCommitsBetween", "tools-id", "tools-1", "tools-2").Return(nil, errors.New("error"))
+
+	repositories := uc.getRepositoryWorkItems(builds, "repo-id")
+
+	assert.Empty(t, repositories)
+}
+
+func TestAppendRepositoryWorkItems(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	mockRepo.On("GetWorkItem", "3").Return(createWorkItem(3, map[string]interface{}{}), nil)
+
+	workItems := uc.appendRepositoryWorkItems([]model.WorkItem{createWorkItem(1, nil)}, map[int][]string{1: {"self"}, 3: {"tools"}})
+
+	assert.Equal(t, []int{1, 3}, []int{workItems[0].Id, workItems[1].Id})
+}
+
+func TestNewReleaseEvent_ShouldTagRepositories(t *testing.T) {
+	builds := []model.PipelineRuns{
+		createMultiRepositoryRun(2, "self-2", "tools-2", "docs-2"),
+		createMultiRepositoryRun(1, "self-1", "tools-1", "docs-1"),
+	}
+	workItems := []model.WorkItem{createWorkItem(1, map[string]interface{}{}), createWorkItem(2, map[string]interface{}{})}
+
+	data := newReleaseEvent(workItems, nil, map[int][]string{2: {"self", "tools"}}, builds, UpdateFieldsParams{}, DefaultIntegrationBuildCodec())
+
+	assert.Nil(t, data.WorkItems[0].Repositories)
+	assert.Equal(t, []string{"self", "tools"}, data.WorkItems[1].Repositories)
+}
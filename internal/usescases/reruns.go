@@ -0,0 +1,47 @@
+package usescases
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	// RerunsNewest keeps the last rerun of a commit, its name is the version
+	RerunsNewest string = "newest"
+	// RerunsOldest keeps the first run of a commit, its name is the version
+	RerunsOldest string = "oldest"
+)
+
+// dedupeReruns keeps one run by ref and source commit, chosen by rule, every run is kept when rule is empty
+// The runs stay sorted from the newest to the oldest id, so a baseline is always older than the run it is compared with
+// The runs without source commit are kept, a branch or a tag cut at the commit of another ref keeps its own run
+func dedupeReruns(runs []model.PipelineRuns, rule string) ([]model.PipelineRuns, error) {
+	if rule == "" {
+		return runs, nil
+	}
+	if rule != RerunsNewest && rule != RerunsOldest {
+		return []model.PipelineRuns{}, fmt.Errorf("%w: %s", ErrInvalidRerunRule, rule)
+	}
+
+	result := make([]model.PipelineRuns, 0, len(runs))
+	positions := map[string]int{}
+	for _, run := range slices.Backward(runs) {
+		if commitId := sourceVersion(run); commitId != "" {
+			key := runRefName(run) + "@" + commitId
+			if position, ok := positions[key]; ok {
+				if rule == RerunsNewest {
+					result[position] = run
+				}
+				continue
+			}
+			positions[key] = len(result)
+		}
+		result = append(result, run)
+	}
+	slices.SortStableFunc(result, func(a, b model.PipelineRuns) int {
+		return b.Id - a.Id
+	})
+	return result, nil
+}
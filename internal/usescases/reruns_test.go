@@ -0,0 +1,102 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeReruns(t *testing.T) {
+	runs := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "25.4.5", 5, "c1"), // rerun of an older commit
+		createRunOnCommit("refs/heads/main", "25.4.4", 4, "c2"),
+		createRunOnCommit("refs/heads/main", "25.4.3", 3, "c2"),
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, ""),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "c1"),
+	}
+	tests := map[string]struct {
+		rule     string
+		expected []int
+	}{
+		"every run": {rule: "", expected: []int{5, 4, 3, 2, 1}},
+		"newest":    {rule: RerunsNewest, expected: []int{5, 4, 2}},
+		"oldest":    {rule: RerunsOldest, expected: []int{3, 2, 1}},
+	}
+
+	for name, test := range tests {
+		t.Run("TestDedupeReruns_"+name, func(t *testing.T) {
+			result, err := dedupeReruns(runs, test.rule)
+
+			assert.Nil(t, err)
+			ids := []int{}
+			for _, run := range result {
+				ids = append(ids, run.Id)
+			}
+			assert.Equal(t, test.expected, ids)
+		})
+	}
+}
+
+func TestDedupeReruns_ShouldKeepRunsOfOtherRefs_OnSameCommit(t *testing.T) {
+	runs := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/release/1.0", "25.4.3", 3, "c1"),
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, "c1"),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "c1"),
+	}
+
+	for _, rule := range []string{RerunsNewest, RerunsOldest} {
+		t.Run("TestDedupeReruns_ShouldKeepRunsOfOtherRefs_"+rule, func(t *testing.T) {
+			result, err := dedupeReruns(runs, rule)
+
+			assert.Nil(t, err)
+			assert.Len(t, result, 2)
+			assert.Equal(t, "refs/heads/release/1.0", runRefName(result[0]))
+			assert.Equal(t, "refs/heads/main", runRefName(result[1]))
+		})
+	}
+}
+
+func TestDedupeReruns_ShouldReturnError_OnInvalidRule(t *testing.T) {
+	_, err := dedupeReruns([]model.PipelineRuns{}, "first")
+
+	assert.ErrorIs(t, err, ErrInvalidRerunRule)
+}
+
+func TestGetRunsToUpdate_ShouldSkipRerunOfSameCommit(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "25.4.3", 3, "c2"),
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, "c2"),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "c1"),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	runs, err := uc.filterRuns(runs, UpdateFieldsParams{RunFilter: RunFilter{Reruns: RerunsOldest}}, nil, nil)
+	assert.Nil(t, err)
+	result, err := uc.getRunsToUpdate(runs, "repo-id", 123, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, []int{result[0].Id, result[1].Id})
+	assert.Equal(t, "25.4.2", result[0].Name)
+}
+
+func TestGetRunsToUpdate_ShouldKeepBaselineOlder_WithNewestRerun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createRunOnCommit("refs/heads/main", "25.4.3", 3, "c1"), // rerun of an older commit
+		createRunOnCommit("refs/heads/main", "25.4.2", 2, "c2"),
+		createRunOnCommit("refs/heads/main", "25.4.1", 1, "c1"),
+	}
+	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	runs, err := uc.filterRuns(runs, UpdateFieldsParams{RunFilter: RunFilter{Reruns: RerunsNewest}}, nil, nil)
+	assert.Nil(t, err)
+	result, err := uc.getRunsToUpdate(runs, "repo-id", 123, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 2}, []int{result[0].Id, result[1].Id})
+	assert.Less(t, result[1].Id, result[0].Id)
+}
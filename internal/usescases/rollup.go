@@ -0,0 +1,158 @@
+package usescases
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
+	"github.com/Damien-Venant/prev-updater/pkg/utils"
+)
+
+const (
+	AdoStateFieldName      string = "System.State"
+	AdoHierarchyForwardRel string = "System.LinkTypes.Hierarchy-Forward"
+	AdoRemovedState        string = "Removed"
+)
+
+type RollupParams struct {
+	WorkItemIds []int
+	FieldName   string
+	Types       []string
+}
+
+// RollupVersions sets the version field of the given work items, and of their ancestors of a rollup type, from their children
+func (u *AdoUsesCases) RollupVersions(param RollupParams) error {
+	workItems := queryslice.TransformParallel(param.WorkItemIds, func(id int, _ int) model.WorkItem {
+		workItem, err := u.Repository.GetWorkItem(strconv.Itoa(id))
+		if err != nil {
+			u.logWarn(err, id, "GetWorkItem")
+			return model.WorkItem{}
+		}
+		return *workItem
+	})
+	return u.rollupParents(workItems, param.FieldName, param.Types)
+}
+
+// rollupParents walks up from workItems and rolls up every ancestor whose type is in types
+// The nearest ancestors are rolled up first so that an Epic sees the version of its Features
+// Each ancestor is read once with its relations, see rollupWorkItem
+func (u *AdoUsesCases) rollupParents(workItems []model.WorkItem, fieldPath string, types []string) error {
+	var errMap error = nil
+	cache := newWorkItemCache(u.Repository)
+	visited := map[int]bool{}
+	level := []int{}
+	for _, workItem := range workItems {
+		if workItem.Id == 0 {
+			continue
+		}
+		if slices.Contains(types, workItemType(workItem)) {
+			visited[workItem.Id] = true
+			if err := u.rollupWorkItem(cache, workItem.Id, fieldPath); err != nil {
+				errMap = errors.Join(errMap, err)
+			}
+		}
+		level = append(level, workItem.Id)
+	}
+
+	for depth := 0; depth < maxHierarchyDepth && len(level) > 0; depth++ {
+		next := []int{}
+		for _, id := range level {
+			current, err := cache.get(id)
+			if err != nil {
+				u.logWarn(err, id, "GetWorkItemWithRelations")
+				continue
+			}
+			parentId, ok := relatedWorkItemId(current.Relations, AdoHierarchyReverseRel)
+			if !ok || visited[parentId] {
+				continue
+			}
+			visited[parentId] = true
+			next = append(next, parentId)
+		}
+
+		for _, id := range next {
+			parent, err := cache.get(id)
+			if err != nil {
+				u.logWarn(err, id, "GetWorkItemWithRelations")
+				continue
+			}
+			if !slices.Contains(types, workItemType(*parent)) {
+				continue
+			}
+			if err := u.rollupWorkItem(cache, id, fieldPath); err != nil {
+				errMap = errors.Join(errMap, err)
+			}
+		}
+		level = next
+	}
+	return errMap
+}
+
+// rollupWorkItem sets the version field of a work item to the highest version of its children
+// Nothing is done while a non-removed child has no version
+// The children are always read again, a child rolled up just before has a new version
+func (u *AdoUsesCases) rollupWorkItem(cache *workItemCache, workItemId int, fieldPath string) error {
+	fieldName := fieldNameFromPath(fieldPath)
+	parent, err := cache.get(workItemId)
+	if err != nil {
+		return err
+	}
+
+	childIds := []int{}
+	for _, relation := range parent.Relations {
+		if relation.Rel != AdoHierarchyForwardRel {
+			continue
+		}
+		if id, err := workItemIdFromUrl(relation.Url); err == nil {
+			childIds = append(childIds, id)
+		}
+	}
+	if len(childIds) == 0 {
+		return nil
+	}
+
+	type childResult struct {
+		workItem *model.WorkItem
+		err      error
+	}
+	results := queryslice.TransformParallel(childIds, func(id int, _ int) childResult {
+		child, err := u.Repository.GetWorkItem(strconv.Itoa(id))
+		return childResult{workItem: child, err: err}
+	})
+	children := make([]*model.WorkItem, 0, len(results))
+	for _, result := range results {
+		if result.err != nil {
+			return result.err
+		}
+		children = append(children, result.workItem)
+	}
+
+	highestVersion, ok := highestChildVersion(children, fieldName)
+	if !ok {
+		return nil
+	}
+	if utils.Coalesce[string](parent.Fields[fieldName], "") == highestVersion {
+		return nil
+	}
+	return u.updateFields(strconv.Itoa(workItemId), highestVersion, fieldPath)
+}
+
+// highestChildVersion returns the highest version among children, or false when a non-removed child has no version
+func highestChildVersion(children []*model.WorkItem, fieldName string) (string, bool) {
+	highestVersion := ""
+	for _, child := range children {
+		if utils.Coalesce[string](child.Fields[AdoStateFieldName], "") == AdoRemovedState {
+			continue
+		}
+		childVersion := utils.Coalesce[string](child.Fields[fieldName], "")
+		if childVersion == "" {
+			return "", false
+		}
+		if highestVersion == "" || newVersion(childVersion).isHigherThan(newVersion(highestVersion)) == 1 {
+			highestVersion = childVersion
+		}
+	}
+	return highestVersion, highestVersion != ""
+}
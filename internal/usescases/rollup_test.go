@@ -0,0 +1,108 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHighestChildVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		children []*model.WorkItem
+		version  string
+		ok       bool
+	}{
+		{
+			name: "AllChildrenHaveVersion",
+			children: []*model.WorkItem{
+				{Id: 1, Fields: map[string]interface{}{"Custom.Version": "25.4.9"}},
+				{Id: 2, Fields: map[string]interface{}{"Custom.Version": "25.4.13"}},
+				{Id: 3, Fields: map[string]interface{}{"Custom.Version": "25.4.2"}},
+			},
+			version: "25.4.13",
+			ok:      true,
+		},
+		{
+			name: "OneChildWithoutVersion",
+			children: []*model.WorkItem{
+				{Id: 1, Fields: map[string]interface{}{"Custom.Version": "25.4.9"}},
+				{Id: 2, Fields: map[string]interface{}{}},
+			},
+			version: "",
+			ok:      false,
+		},
+		{
+			name: "RemovedChildWithoutVersion",
+			children: []*model.WorkItem{
+				{Id: 1, Fields: map[string]interface{}{"Custom.Version": "25.4.9"}},
+				{Id: 2, Fields: map[string]interface{}{AdoStateFieldName: AdoRemovedState}},
+			},
+			version: "25.4.9",
+			ok:      true,
+		},
+		{
+			name: "OnlyRemovedChildren",
+			children: []*model.WorkItem{
+				{Id: 2, Fields: map[string]interface{}{AdoStateFieldName: AdoRemovedState}},
+			},
+			version: "",
+			ok:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("TestHighestChildVersion_"+test.name, func(t *testing.T) {
+			version, ok := highestChildVersion(test.children, "Custom.Version")
+			assert.Equal(t, test.version, version)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
+
+func TestRollupParents_ShouldUpdateFeatureThenEpic(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	story := createWorkItem(1, map[string]interface{}{AdoWorkItemTypeFieldName: "User Story", "Custom.Version": "25.4.13"})
+	mockRepo.On("GetWorkItemWithRelations", "1").Return(model.WorkItem{Id: 1, Relations: []model.WorkItemRelation{createRelation(AdoHierarchyReverseRel, "10")}}, nil)
+	mockRepo.On("GetWorkItemWithRelations", "10").Return(model.WorkItem{Id: 10, Fields: map[string]interface{}{AdoWorkItemTypeFieldName: "Feature"}, Relations: []model.WorkItemRelation{
+		createRelation(AdoHierarchyReverseRel, "100"),
+		createRelation(AdoHierarchyForwardRel, "1"),
+		createRelation(AdoHierarchyForwardRel, "2"),
+	}}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(story, nil)
+	mockRepo.On("GetWorkItem", "2").Return(createWorkItem(2, map[string]interface{}{"Custom.Version": "25.4.2"}), nil)
+	// the Feature is read again as a child of the Epic, not from the cache, to get its new version
+	mockRepo.On("GetWorkItem", "10").Return(createWorkItem(10, map[string]interface{}{AdoWorkItemTypeFieldName: "Feature"}), nil)
+	mockRepo.On("GetWorkItemWithRelations", "100").Return(model.WorkItem{Id: 100, Fields: map[string]interface{}{AdoWorkItemTypeFieldName: "Epic"}, Relations: []model.WorkItemRelation{
+		createRelation(AdoHierarchyForwardRel, "10"),
+		createRelation(AdoHierarchyForwardRel, "20"),
+	}}, nil)
+	mockRepo.On("GetWorkItem", "20").Return(createWorkItem(20, map[string]interface{}{}), nil)
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.rollupParents([]model.WorkItem{story}, "/fields/Custom.Version", []string{"Feature", "Epic"})
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "10", model.OperationFields{Op: "add", Path: "/fields/Custom.Version", Value: "25.4.13"})
+	mockRepo.AssertNumberOfCalls(t, "UpdateWorkitemField", 1)
+	mockRepo.AssertNumberOfCalls(t, "GetWorkItemWithRelations", 3)
+}
+
+func TestRollupWorkItem_ShouldNotUpdateWhenVersionIsUnchanged(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetWorkItemWithRelations", "10").Return(model.WorkItem{Id: 10, Fields: map[string]interface{}{"Custom.Version": "25.4.13"}, Relations: []model.WorkItemRelation{
+		createRelation(AdoHierarchyForwardRel, "1"),
+	}}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, map[string]interface{}{"Custom.Version": "25.4.13"}), nil)
+
+	err := uc.rollupWorkItem(newWorkItemCache(mockRepo), 10, "/fields/Custom.Version")
+
+	assert.Nil(t, err)
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemField", mock.Anything, mock.Anything)
+}
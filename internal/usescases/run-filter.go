@@ -0,0 +1,108 @@
+package usescases
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
+)
+
+const (
+	AdoIndividualCIReason string = "individualCI"
+	AdoBatchedCIReason    string = "batchedCI"
+	AdoBranchRefs         string = "refs/heads/*"
+)
+
+// RunFilter restricts the runs that can be the last run or the baseline, an empty list accepts any value
+type RunFilter struct {
+	// Results of the completed runs, e.g. succeeded or partiallySucceeded
+	Results []string
+	// Reasons that triggered the runs, e.g. individualCI, batchedCI, schedule, pullRequest or manual
+	Reasons []string
+	// Refs of the runs, a trailing * matches any ref with this prefix, e.g. refs/heads/*
+	Refs []string
+	// Reruns keeps one run by source commit, the newest or the oldest, every run is kept when it is empty
+	Reruns string
+}
+
+// applyRunFilter keeps the runs matching the filter, the reasons are read from the builds API when they are filtered
+// or when withBuilds is set, e.g. for the tags read by the tag baseline
+// A run kept by a stage while still in progress has no result yet, so it isn't filtered on its result
+// A run whose build isn't found has no reason, it is kept rather than silently dropped
+func (u *AdoUsesCases) applyRunFilter(runs []model.PipelineRuns, filter RunFilter, withBuilds bool) ([]model.PipelineRuns, error) {
+	if (len(filter.Reasons) > 0 || withBuilds) && len(runs) > 0 {
+		builds, err := u.Repository.GetBuildsByIds(queryslice.Transform(runs, func(run model.PipelineRuns, _ int) int {
+			return run.Id
+		}))
+		if err != nil {
+			return []model.PipelineRuns{}, err
+		}
+		runs = withBuildReasons(runs, builds)
+		if len(filter.Reasons) > 0 {
+			u.logUnknownReasons(runs)
+		}
+	}
+
+	return queryslice.Filter(runs, func(pre model.PipelineRuns) bool {
+		if len(filter.Results) > 0 && pre.State == AdoCompletedState && !containsFold(filter.Results, pre.Result) {
+			return false
+		}
+		if len(filter.Reasons) > 0 && pre.Reason != "" && !containsFold(filter.Reasons, pre.Reason) {
+			return false
+		}
+		return len(filter.Refs) == 0 || slices.ContainsFunc(filter.Refs, func(ref string) bool {
+			return matchRef(ref, runRefName(pre))
+		})
+	}), nil
+}
+
+func (u *AdoUsesCases) logUnknownReasons(runs []model.PipelineRuns) {
+	unknown := []int{}
+	for _, run := range runs {
+		if run.Reason == "" {
+			unknown = append(unknown, run.Id)
+		}
+	}
+	if len(unknown) == 0 || u.Logger == nil {
+		return
+	}
+	u.Logger.Warn().
+		Ints("run-ids", unknown).
+		Msg("The reason of the runs is unknown, they are kept")
+}
+
+// withBuildReasons copies the reason, trigger info and tags of each build on its run
+func withBuildReasons(runs []model.PipelineRuns, builds []model.Build) []model.PipelineRuns {
+	byId := make(map[int]model.Build, len(builds))
+	for _, build := range builds {
+		byId[build.Id] = build
+	}
+	result := make([]model.PipelineRuns, len(runs))
+	for index, run := range runs {
+		if build, ok := byId[run.Id]; ok {
+			run.Reason = build.Reason
+			run.TriggerInfo = build.TriggerInfo
+			run.Tags = build.Tags
+		}
+		result[index] = run
+	}
+	return result
+}
+
+func matchRef(pattern string, refName string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(refName, prefix)
+	}
+	return refName == pattern
+}
+
+func runRefName(run model.PipelineRuns) string {
+	return selfRepository(run).RefName
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(val string) bool {
+		return strings.EqualFold(val, value)
+	})
+}
@@ -0,0 +1,123 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRunWithResult(ref string, id int, result string) model.PipelineRuns {
+	run := createPipelineRun(ref, "", id)
+	run.Result = result
+	return run
+}
+
+func TestMatchRef(t *testing.T) {
+	tests := map[string]struct {
+		pattern  string
+		refName  string
+		expected bool
+	}{
+		"branch prefix":     {pattern: AdoBranchRefs, refName: "refs/heads/feature/login", expected: true},
+		"pull request":      {pattern: AdoBranchRefs, refName: "refs/pull/12/merge", expected: false},
+		"exact ref":         {pattern: "refs/heads/main", refName: "refs/heads/main", expected: true},
+		"exact other ref":   {pattern: "refs/heads/main", refName: "refs/heads/main-old", expected: false},
+		"any ref":           {pattern: "*", refName: "refs/tags/v1", expected: true},
+		"tag prefix on tag": {pattern: "refs/tags/*", refName: "refs/tags/v1", expected: true},
+	}
+
+	for name, test := range tests {
+		t.Run("TestMatchRef_"+name, func(t *testing.T) {
+			assert.Equal(t, test.expected, matchRef(test.pattern, test.refName))
+		})
+	}
+}
+
+func TestApplyRunFilter_ShouldFilterResultsAndRefs(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+	runs := []model.PipelineRuns{
+		createRunWithResult("refs/pull/12/merge", 5, "succeeded"),
+		createRunWithResult("refs/heads/main", 4, "failed"),
+		createRunWithResult("refs/heads/main", 3, "canceled"),
+		createRunWithResult("refs/heads/main", 2, "Succeeded"),
+		createRunWithResult("refs/heads/main", 1, "succeeded"),
+	}
+
+	result, err := uc.applyRunFilter(runs, RunFilter{Results: []string{"succeeded"}, Refs: []string{AdoBranchRefs}}, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, runs[3:], result)
+}
+
+func TestApplyRunFilter_ShouldKeepInProgressRunsWithoutResult(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+	inProgress := createRunWithResult("refs/heads/main", 2, "")
+	inProgress.State = "inProgress"
+
+	result, err := uc.applyRunFilter([]model.PipelineRuns{inProgress}, RunFilter{Results: []string{"succeeded"}}, false)
+
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestApplyRunFilter_ShouldFilterReasonsFromBuilds(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createRunWithResult("refs/heads/main", 3, "succeeded"),
+		createRunWithResult("refs/heads/main", 2, "succeeded"),
+		createRunWithResult("refs/heads/main", 1, "succeeded"),
+	}
+
+	mockRepo.On("GetBuildsByIds", []int{3, 2, 1}).Return([]model.Build{
+		{Id: 3, Reason: "schedule"},
+		{Id: 2, Reason: AdoIndividualCIReason, TriggerInfo: map[string]string{"ci.sourceSha": "abc123"}},
+		{Id: 1, Reason: AdoBatchedCIReason},
+	}, nil)
+
+	result, err := uc.applyRunFilter(runs, RunFilter{Reasons: []string{AdoIndividualCIReason, AdoBatchedCIReason}}, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, []int{result[0].Id, result[1].Id})
+	assert.Equal(t, AdoIndividualCIReason, result[0].Reason)
+	assert.Equal(t, "abc123", result[0].TriggerInfo["ci.sourceSha"])
+}
+
+func TestApplyRunFilter_ShouldKeepRunsWithUnknownReason(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createRunWithResult("refs/heads/main", 2, "succeeded"),
+		createRunWithResult("refs/heads/main", 1, "succeeded"),
+	}
+
+	mockRepo.On("GetBuildsByIds", []int{2, 1}).Return([]model.Build{{Id: 2, Reason: "schedule"}}, nil)
+
+	result, err := uc.applyRunFilter(runs, RunFilter{Reasons: []string{AdoIndividualCIReason}}, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{runs[1]}, result)
+}
+
+func TestApplyRunFilter_ShouldReturnErrorOnBuilds(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetBuildsByIds", []int{1}).Return(nil, errors.New("error"))
+
+	_, err := uc.applyRunFilter([]model.PipelineRuns{createRunWithResult("refs/heads/main", 1, "succeeded")}, RunFilter{Reasons: []string{AdoIndividualCIReason}}, false)
+
+	assert.NotNil(t, err)
+}
+
+func TestApplyRunFilter_WithoutFilterShouldKeepAllRuns(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+	runs := []model.PipelineRuns{createRunWithResult("refs/pull/1/merge", 1, "failed")}
+
+	result, err := uc.applyRunFilter(runs, RunFilter{}, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, runs, result)
+}
@@ -0,0 +1,107 @@
+package usescases
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+// RunRange selects the baseline and the run explicitly, by run id or by date
+// FromRunId or Since sets the baseline, ToRunId or Until the run, which is the last run by default
+type RunRange struct {
+	FromRunId int
+	ToRunId   int
+	// Since uses the last run created before this date as baseline, to get every run since this date
+	Since time.Time
+	// Until uses the last run created until this date
+	Until time.Time
+}
+
+func (r RunRange) isSet() bool {
+	return r.FromRunId != 0 || r.ToRunId != 0 || !r.Since.IsZero() || !r.Until.IsZero()
+}
+
+// getRunsInRange returns the run and its baseline selected by param.Range
+// The runs selected by date are filtered like the last runs, on the branch when it is set
+func (u *AdoUsesCases) getRunsInRange(param UpdateFieldsParams, branch BranchMatcher) ([]model.PipelineRuns, error) {
+	runRange := *param.Range
+	if runRange.FromRunId == 0 && runRange.Since.IsZero() {
+		return []model.PipelineRuns{}, fmt.Errorf("%w: a from-run or a since date is required", ErrInvalidRunRange)
+	}
+	if !runRange.Since.IsZero() && !runRange.Until.IsZero() && !runRange.Since.Before(runRange.Until) {
+		return []model.PipelineRuns{}, ErrInvalidRunRange
+	}
+
+	isTo := func(run model.PipelineRuns) bool {
+		return runRange.Until.IsZero() || !run.CreatedDate.After(runRange.Until)
+	}
+	isFrom := func(run model.PipelineRuns) bool {
+		return run.CreatedDate.Before(runRange.Since)
+	}
+	var runs []model.PipelineRuns
+	if runRange.FromRunId == 0 || runRange.ToRunId == 0 {
+		result, err := u.Repository.GetPipelineRuns(param.PipelineId)
+		if err != nil {
+			return []model.PipelineRuns{}, err
+		}
+		found := func(runs []model.PipelineRuns) bool {
+			if branch != nil {
+				runs = filterRunsOnBranch(runs, branch)
+			}
+			return (runRange.ToRunId != 0 || slices.ContainsFunc(runs, isTo)) && (runRange.FromRunId != 0 || slices.ContainsFunc(runs, isFrom))
+		}
+		if runs, err = u.filterRuns(result, param, branch, found); err != nil {
+			return []model.PipelineRuns{}, err
+		}
+		if branch != nil {
+			runs = filterRunsOnBranch(runs, branch)
+		}
+	}
+
+	to, err := u.getRangeBound(runs, param.PipelineId, runRange.ToRunId, isTo)
+	if err != nil {
+		return []model.PipelineRuns{}, err
+	}
+	from, err := u.getRangeBound(runs, param.PipelineId, runRange.FromRunId, isFrom)
+	if err != nil {
+		return []model.PipelineRuns{}, err
+	}
+
+	if from.Id == to.Id || !from.CreatedDate.Before(to.CreatedDate) {
+		return []model.PipelineRuns{}, fmt.Errorf("%w: run %d isn't older than run %d", ErrInvalidRunRange, from.Id, to.Id)
+	}
+	return []model.PipelineRuns{*to, *from}, nil
+}
+
+// getRangeBound returns the run runId of the pipeline, or the last run of runs matching the predicate when runId is zero
+func (u *AdoUsesCases) getRangeBound(runs []model.PipelineRuns, pipelineId int, runId int, predicate func(model.PipelineRuns) bool) (*model.PipelineRuns, error) {
+	if runId == 0 {
+		for _, run := range runs {
+			if predicate(run) {
+				return &run, nil
+			}
+		}
+		return nil, ErrNoRunInRange
+	}
+
+	run, err := u.Repository.GetPipelineRun(pipelineId, runId)
+	if err != nil {
+		return nil, fmt.Errorf("run %d: %w", runId, err)
+	}
+	if run.Pipeline != nil && run.Pipeline.Id != pipelineId {
+		return nil, fmt.Errorf("%w: run %d, pipeline %d", ErrRunNotInPipeline, runId, pipelineId)
+	}
+	return run, nil
+}
+
+func filterRunsOnBranch(runs []model.PipelineRuns, branch BranchMatcher) []model.PipelineRuns {
+	result := []model.PipelineRuns{}
+	for _, run := range runs {
+		if branch.Match(runRefName(run)) {
+			result = append(result, run)
+		}
+	}
+	return result
+}
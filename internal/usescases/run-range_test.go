@@ -0,0 +1,108 @@
+package usescases
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnknownRun = errors.New("not found")
+
+func createRunAt(ref string, id int, day int) model.PipelineRuns {
+	run := createPipelineRun(ref, "", id)
+	run.CreatedDate = time.Date(2025, 4, day, 10, 0, 0, 0, time.UTC)
+	run.Pipeline = &model.PipelineReference{Id: 7}
+	return run
+}
+
+func TestGetRunsInRange_ByRunIds(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	from, to := createRunAt("refs/heads/main", 40, 10), createRunAt("refs/heads/main", 42, 12)
+
+	mockRepo.On("GetPipelineRun", 7, 40).Return(from, nil)
+	mockRepo.On("GetPipelineRun", 7, 42).Return(to, nil)
+
+	result, err := uc.getRunsInRange(UpdateFieldsParams{PipelineId: 7, Range: &RunRange{FromRunId: 40, ToRunId: 42}}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{to, from}, result)
+	mockRepo.AssertNotCalled(t, "GetPipelineRuns", 7)
+}
+
+func TestGetRunsInRange_ByDates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createRunAt("refs/heads/main", 5, 15),
+		createRunAt("refs/heads/main", 4, 13),
+		createRunAt("refs/heads/feature", 3, 12),
+		createRunAt("refs/heads/main", 2, 9),
+		createRunAt("refs/heads/main", 1, 8),
+	}
+
+	mockRepo.On("GetPipelineRuns", 7).Return(runs, nil)
+
+	result, err := uc.getRunsInRange(UpdateFieldsParams{
+		PipelineId: 7,
+		BranchName: "main",
+		Range: &RunRange{
+			Since: time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC),
+			Until: time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC),
+		},
+	}, ExactBranch("main"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{runs[1], runs[3]}, result)
+}
+
+func TestGetRunsInRange_FromRunToLastRun(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{createRunAt("refs/heads/main", 5, 15), createRunAt("refs/heads/main", 4, 13)}
+
+	mockRepo.On("GetPipelineRuns", 7).Return(runs, nil)
+	mockRepo.On("GetPipelineRun", 7, 2).Return(createRunAt("refs/heads/main", 2, 9), nil)
+
+	result, err := uc.getRunsInRange(UpdateFieldsParams{PipelineId: 7, Range: &RunRange{FromRunId: 2}}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5, 2}, []int{result[0].Id, result[1].Id})
+}
+
+func TestGetRunsInRange_Errors(t *testing.T) {
+	tests := map[string]struct {
+		runRange    RunRange
+		expectedErr error
+	}{
+		"without from":        {runRange: RunRange{ToRunId: 42}, expectedErr: ErrInvalidRunRange},
+		"since after until":   {runRange: RunRange{Since: time.Date(2025, 4, 14, 0, 0, 0, 0, time.UTC), Until: time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)}, expectedErr: ErrInvalidRunRange},
+		"from newer than to":  {runRange: RunRange{FromRunId: 42, ToRunId: 40}, expectedErr: ErrInvalidRunRange},
+		"same run":            {runRange: RunRange{FromRunId: 40, ToRunId: 40}, expectedErr: ErrInvalidRunRange},
+		"other pipeline":      {runRange: RunRange{FromRunId: 30, ToRunId: 42}, expectedErr: ErrRunNotInPipeline},
+		"no run before since": {runRange: RunRange{Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), ToRunId: 42}, expectedErr: ErrNoRunInRange},
+		"unknown run":         {runRange: RunRange{FromRunId: 1, ToRunId: 42}, expectedErr: errUnknownRun},
+	}
+
+	for name, test := range tests {
+		t.Run("TestGetRunsInRange_"+name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			uc := AdoUsesCases{Repository: mockRepo}
+			otherPipeline := createRunAt("refs/heads/main", 30, 1)
+			otherPipeline.Pipeline.Id = 8
+
+			mockRepo.On("GetPipelineRuns", 7).Return([]model.PipelineRuns{createRunAt("refs/heads/main", 42, 12)}, nil)
+			mockRepo.On("GetPipelineRun", 7, 40).Return(createRunAt("refs/heads/main", 40, 10), nil)
+			mockRepo.On("GetPipelineRun", 7, 42).Return(createRunAt("refs/heads/main", 42, 12), nil)
+			mockRepo.On("GetPipelineRun", 7, 30).Return(otherPipeline, nil)
+			mockRepo.On("GetPipelineRun", 7, 1).Return(nil, errUnknownRun)
+
+			_, err := uc.getRunsInRange(UpdateFieldsParams{PipelineId: 7, Range: &test.runRange}, nil)
+
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package usescases
+
+import (
+	"fmt"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+const (
+	RepositoryTypeAzureReposGit string = "azureReposGit"
+	RepositoryTypeGitHub        string = "gitHub"
+	RepositoryTypeBitbucket     string = "bitbucket"
+)
+
+// SourceHost reads the repositories hosted outside of Azure Repos, like GitHub or Bitbucket
+type SourceHost interface {
+	DefaultBranch(repository model.RunRepository) (string, error)
+}
+
+// runRepository returns the source repository of the run, nil when the run doesn't tell it
+func runRepository(run model.PipelineRuns) *model.RunRepository {
+	return selfRepository(run).Repository
+}
+
+// getDefaultBranch returns the default branch of the source repository of the run
+// An Azure Repos repository is read with the Git API, the other ones with the source host of their type
+// The Azure Repos repository is repositoryId, or the repository of the run when it is empty
+func (u *AdoUsesCases) getDefaultBranch(repositoryId string, run model.PipelineRuns) (string, error) {
+	repository := runRepository(run)
+	if repository == nil || repository.Type == "" || repository.Type == RepositoryTypeAzureReposGit {
+		if repositoryId == "" && repository != nil {
+			repositoryId = repository.Id
+		}
+		if repositoryId == "" {
+			return "", fmt.Errorf("%w: the repository of the run is unknown", ErrUnknownDefaultBranch)
+		}
+		gitRepository, err := u.Repository.GetRepositoryById(repositoryId)
+		if err != nil {
+			return "", err
+		}
+		return gitRepository.DefaultBranch, nil
+	}
+
+	host, ok := u.SourceHosts[repository.Type]
+	if !ok {
+		return "", fmt.Errorf("%w: %s repository %s", ErrUnknownDefaultBranch, repository.Type, repository.FullName)
+	}
+	return host.DefaultBranch(*repository)
+}
@@ -0,0 +1,93 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSourceHost struct {
+	mock.Mock
+}
+
+func (m *MockSourceHost) DefaultBranch(repository model.RunRepository) (string, error) {
+	args := m.Called(repository)
+	return args.String(0), args.Error(1)
+}
+
+func createGitHubRun(ref string, id int) model.PipelineRuns {
+	run := createPipelineRun(ref, "", id)
+	run.Resources.Repositories[SelfRepositoryAlias] = model.RepositoryResource{
+		RefName:    ref,
+		Repository: &model.RunRepository{Type: RepositoryTypeGitHub, FullName: "org/product"},
+	}
+	return run
+}
+
+func TestGetRunsToUpdate_GitHub_ShouldUseSourceHost(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockHost := new(MockSourceHost)
+	uc := &AdoUsesCases{Repository: mockRepo, SourceHosts: map[string]SourceHost{RepositoryTypeGitHub: mockHost}}
+	builds := []model.PipelineRuns{
+		createGitHubRun("refs/heads/feature-1", 3),
+		createGitHubRun("refs/heads/develop", 2),
+		createGitHubRun("refs/heads/main", 1),
+	}
+	mockHost.On("DefaultBranch", model.RunRepository{Type: RepositoryTypeGitHub, FullName: "org/product"}).Return("refs/heads/develop", nil)
+
+	result, err := uc.getRunsToUpdate(builds, "github-repo", 123, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[1]}, result)
+	mockRepo.AssertNotCalled(t, "GetRepositoryById", mock.Anything)
+}
+
+func TestGetRunsToUpdate_GitHub_WithoutSourceHost(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createGitHubRun("refs/heads/main", 2),
+		createGitHubRun("refs/heads/main", 1),
+	}
+
+	result, err := uc.getRunsToUpdate(builds, "github-repo", 123, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[1]}, result)
+
+	_, err = uc.getRunsToUpdate(builds[:1], "github-repo", 123, nil)
+	assert.ErrorIs(t, err, ErrUnknownDefaultBranch)
+}
+
+func TestGetRunsToUpdate_ShouldUseDefaultBranchOption(t *testing.T) {
+	uc := &AdoUsesCases{Repository: new(MockRepository)}
+	builds := []model.PipelineRuns{
+		createGitHubRun("refs/heads/feature-1", 3),
+		createGitHubRun("refs/heads/trunk", 2),
+	}
+
+	strategies, err := uc.baselineStrategies(UpdateFieldsParams{RepositoryId: "github-repo", DefaultBranch: "trunk"})
+	assert.Nil(t, err)
+	result, err := uc.getRunsToUpdate(builds, "github-repo", 123, nil, strategies...)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{builds[0], builds[1]}, result)
+}
+
+func TestGetDefaultBranch_ShouldReadRepositoryOfRun_WithoutRepositoryId(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	run := createPipelineRun("refs/heads/main", "", 1)
+	run.Resources.Repositories[SelfRepositoryAlias] = model.RepositoryResource{
+		RefName:    "refs/heads/main",
+		Repository: &model.RunRepository{Id: "run-repo", Type: RepositoryTypeAzureReposGit},
+	}
+	mockRepo.On("GetRepositoryById", "run-repo").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
+
+	defaultBranch, err := uc.getDefaultBranch("", run)
+	assert.Nil(t, err)
+	assert.Equal(t, "refs/heads/main", defaultBranch)
+
+	_, err = uc.getDefaultBranch("", createPipelineRun("refs/heads/main", "", 1))
+	assert.ErrorIs(t, err, ErrUnknownDefaultBranch)
+}
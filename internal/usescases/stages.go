@@ -0,0 +1,215 @@
+package usescases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
+)
+
+const (
+	AdoStageRecordType string = "Stage"
+	AdoSucceededResult string = "succeeded"
+	AdoCompletedState  string = "completed"
+
+	// maxTimelineRequests is the number of run timelines read at the same time, to stay under the ADO rate limits
+	maxTimelineRequests int = 8
+)
+
+type (
+	// StageParams keeps only the runs whose stage succeeded, or that were deployed to the environment
+	// A run waiting on a later stage is kept as soon as the chosen stage succeeded
+	StageParams struct {
+		Stage       string
+		Environment string
+	}
+
+	// EnvironmentField is the field receiving the version once the run is deployed to the environment
+	EnvironmentField struct {
+		Environment string
+		FieldName   string
+	}
+
+	// runStage is the result of the stage of a run, err is set when its timeline can't be read
+	runStage struct {
+		succeeded bool
+		err       error
+	}
+)
+
+// ParseEnvironmentField parses a field like 'QA=Custom.DeployedToQA' or 'QA=/fields/Custom.DeployedToQA'
+func ParseEnvironmentField(value string) (EnvironmentField, error) {
+	environment, field, ok := strings.Cut(value, "=")
+	if !ok || environment == "" || field == "" {
+		return EnvironmentField{}, ErrInvalidEnvironmentField
+	}
+	if !strings.HasPrefix(field, "/fields/") {
+		field = "/fields/" + strings.TrimPrefix(field, "/")
+	}
+	return EnvironmentField{Environment: environment, FieldName: field}, nil
+}
+
+// UpdateFieldsByEnvironments sets the field of each environment from the last runs deployed to it,
+// the other updates (tags, comments, notifications...) are done once per environment
+// Every environment is updated even when another one fails, without field it is UpdateFieldsByBranches
+func (u *AdoUsesCases) UpdateFieldsByEnvironments(param UpdateFieldsParams, branches []string, fields []EnvironmentField) error {
+	if len(fields) == 0 {
+		return u.UpdateFieldsByBranches(param, branches)
+	}
+	var errMap error = nil
+	for _, field := range fields {
+		environmentParam := param
+		environmentParam.FieldName = field.FieldName
+		environmentParam.Stage = &StageParams{Environment: field.Environment}
+		if param.Stage != nil {
+			environmentParam.Stage.Stage = param.Stage.Stage
+		}
+		if err := u.UpdateFieldsByBranches(environmentParam, branches); err != nil {
+			errMap = errors.Join(errMap, fmt.Errorf("environment %s: %w", field.Environment, err))
+		}
+	}
+	return errMap
+}
+
+// filterRuns returns the runs that can be the last run or the baseline
+// When a stage is checked on the timelines, the runs older than the ones found accepts are not returned, see filterRunsByStage
+func (u *AdoUsesCases) filterRuns(runs []model.PipelineRuns, param UpdateFieldsParams, branch BranchMatcher, found func([]model.PipelineRuns) bool) ([]model.PipelineRuns, error) {
+	runs, err := u.applyRunFilter(runs, param.RunFilter, slices.Contains(param.Baselines, BaselineTag))
+	if err != nil {
+		return []model.PipelineRuns{}, err
+	}
+	if param.Stage != nil && param.Stage.Environment != "" {
+		if runs, err = u.filterRunsByEnvironment(runs, param.PipelineId, *param.Stage); err != nil {
+			return []model.PipelineRuns{}, err
+		}
+	} else if param.Stage != nil && param.Stage.Stage != "" {
+		return u.filterRunsByStage(runs, param.Stage.Stage, param.RunFilter.Reruns, branch, found)
+	} else {
+		runs = queryslice.Filter(runs, func(pre model.PipelineRuns) bool {
+			return pre.State == AdoCompletedState
+		})
+	}
+	return dedupeReruns(runs, param.RunFilter.Reruns)
+}
+
+func (u *AdoUsesCases) filterRunsByEnvironment(runs []model.PipelineRuns, pipelineId int, param StageParams) ([]model.PipelineRuns, error) {
+	deployed, err := u.getDeployedRuns(pipelineId, param)
+	if err != nil {
+		return []model.PipelineRuns{}, err
+	}
+	return queryslice.Filter(runs, func(pre model.PipelineRuns) bool {
+		return deployed[pre.Id]
+	}), nil
+}
+
+// filterRunsByStage keeps the runs whose stage succeeded, deduplicated by reruns
+// The timelines are read from the first run on branch, the newer runs can't be the last run nor a baseline,
+// by batches of maxTimelineRequests until found accepts the runs kept so far, every run is read when found is nil
+// A timeline that can't be read is an error, so the baseline never moves silently to an older run
+func (u *AdoUsesCases) filterRunsByStage(runs []model.PipelineRuns, stage string, reruns string, branch BranchMatcher, found func([]model.PipelineRuns) bool) ([]model.PipelineRuns, error) {
+	first := findBranchRun(runs, branch)
+	if first < 0 {
+		return []model.PipelineRuns{}, nil
+	}
+	runs = runs[first:]
+
+	result := []model.PipelineRuns{}
+	for batch := range slices.Chunk(runs, maxTimelineRequests) {
+		stages := queryslice.TransformParallelLimit(batch, maxTimelineRequests, func(run model.PipelineRuns, _ int) runStage {
+			timeline, err := u.Repository.GetRunTimeline(run.Id)
+			if err != nil {
+				return runStage{err: err}
+			}
+			return runStage{succeeded: stageSucceeded(*timeline, stage)}
+		})
+		for index, checked := range stages {
+			if checked.err != nil {
+				return []model.PipelineRuns{}, fmt.Errorf("timeline of run %d: %w", batch[index].Id, checked.err)
+			}
+			if checked.succeeded {
+				result = append(result, batch[index])
+			}
+		}
+		if found == nil {
+			continue
+		}
+		deduped, err := dedupeReruns(result, reruns)
+		if err != nil || found(deduped) {
+			return deduped, err
+		}
+	}
+	return dedupeReruns(result, reruns)
+}
+
+// findBranchRun returns the index of the first run on branch, 0 when branch is nil and -1 when no run is on it
+func findBranchRun(runs []model.PipelineRuns, branch BranchMatcher) int {
+	if branch == nil {
+		if len(runs) == 0 {
+			return -1
+		}
+		return 0
+	}
+	return queryslice.FindIndex(runs, func(pre model.PipelineRuns) bool {
+		return branch.Match(runRefName(pre))
+	})
+}
+
+// runsToUpdateFound tells if runs are enough to find the last run on branch and its baseline
+// The first strategy finding a baseline in the runs read so far is used, even when a previous one would find an older run
+func (u *AdoUsesCases) runsToUpdateFound(repositoryId string, branch BranchMatcher, strategies []BaselineStrategy) func([]model.PipelineRuns) bool {
+	return func(runs []model.PipelineRuns) bool {
+		index := findBranchRun(runs, branch)
+		if index < 0 {
+			return false
+		}
+		if len(strategies) == 0 {
+			var err error
+			if strategies, err = u.defaultBaselineStrategies(repositoryId, runs[index]); err != nil {
+				// getRunsToUpdate returns the error
+				return true
+			}
+		}
+		_, err := findBaseline(strategies, runs[index], runs[index+1:])
+		return err == nil
+	}
+}
+
+// getDeployedRuns returns the runs of the pipeline successfully deployed to the environment, by the stage when it is set
+func (u *AdoUsesCases) getDeployedRuns(pipelineId int, param StageParams) (map[int]bool, error) {
+	environment, err := u.Repository.GetEnvironmentByName(param.Environment)
+	if err != nil {
+		return nil, err
+	}
+	records, err := u.Repository.GetEnvironmentDeploymentRecords(environment.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	deployed := map[int]bool{}
+	for _, record := range records {
+		if record.Definition.Id != pipelineId || record.Result != AdoSucceededResult {
+			continue
+		}
+		if param.Stage != "" && !strings.EqualFold(record.StageName, param.Stage) {
+			continue
+		}
+		deployed[record.Owner.Id] = true
+	}
+	return deployed, nil
+}
+
+// stageSucceeded checks the stage by its display name or its identifier
+func stageSucceeded(timeline model.Timeline, stage string) bool {
+	for _, record := range timeline.Records {
+		if record.Type != AdoStageRecordType {
+			continue
+		}
+		if strings.EqualFold(record.Name, stage) || strings.EqualFold(record.Identifier, stage) {
+			return record.Result == AdoSucceededResult
+		}
+	}
+	return false
+}
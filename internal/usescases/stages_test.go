@@ -0,0 +1,189 @@
+package usescases
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createTimeline(stage string, result string) model.Timeline {
+	return model.Timeline{
+		Records: []model.TimelineRecord{
+			{Type: AdoStageRecordType, Name: "Build", Identifier: "build", Result: AdoSucceededResult},
+			{Type: "Job", Name: stage, Result: AdoSucceededResult},
+			{Type: AdoStageRecordType, Name: stage, Identifier: strings.ToLower(stage), Result: result},
+		},
+	}
+}
+
+func createDeploymentRecord(runId int, pipelineId int, stage string, result string) model.EnvironmentDeploymentRecord {
+	record := model.EnvironmentDeploymentRecord{StageName: stage, Result: result}
+	record.Owner.Id = runId
+	record.Definition.Id = pipelineId
+	return record
+}
+
+func TestStageSucceeded(t *testing.T) {
+	tests := map[string]struct {
+		timeline model.Timeline
+		stage    string
+		expected bool
+	}{
+		"by name":          {timeline: createTimeline("QA", AdoSucceededResult), stage: "QA", expected: true},
+		"by identifier":    {timeline: createTimeline("QA", AdoSucceededResult), stage: "qa", expected: true},
+		"failed stage":     {timeline: createTimeline("QA", "failed"), stage: "QA", expected: false},
+		"pending stage":    {timeline: createTimeline("QA", ""), stage: "QA", expected: false},
+		"missing stage":    {timeline: createTimeline("QA", AdoSucceededResult), stage: "Prod", expected: false},
+		"job is not stage": {timeline: model.Timeline{Records: []model.TimelineRecord{{Type: "Job", Name: "QA", Result: AdoSucceededResult}}}, stage: "QA", expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run("TestStageSucceeded_"+name, func(t *testing.T) {
+			assert.Equal(t, test.expected, stageSucceeded(test.timeline, test.stage))
+		})
+	}
+}
+
+func TestFilterRuns_ShouldKeepCompletedRunsByDefault(t *testing.T) {
+	uc := AdoUsesCases{Repository: new(MockRepository)}
+	inProgress := createPipelineRun("refs/heads/main", "25.4.14", 3)
+	inProgress.State = "inProgress"
+	runs := []model.PipelineRuns{inProgress, createPipelineRun("refs/heads/main", "25.4.13", 2)}
+
+	result, err := uc.filterRuns(runs, UpdateFieldsParams{}, nil, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, runs[1:], result)
+}
+
+func TestFilterRuns_ShouldKeepRunsWhoseStageSucceeded(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	waitingForProd := createPipelineRun("refs/heads/main", "25.4.14", 4)
+	waitingForProd.State = "inProgress"
+	runs := []model.PipelineRuns{
+		waitingForProd,
+		createPipelineRun("refs/heads/main", "25.4.13", 3),
+		createPipelineRun("refs/heads/main", "25.4.12", 2),
+		createPipelineRun("refs/heads/main", "25.4.11", 1),
+	}
+
+	mockRepo.On("GetRunTimeline", 4).Return(createTimeline("QA", AdoSucceededResult), nil)
+	mockRepo.On("GetRunTimeline", 3).Return(createTimeline("QA", "failed"), nil)
+	mockRepo.On("GetRunTimeline", 2).Return(createTimeline("QA", ""), nil)
+	mockRepo.On("GetRunTimeline", 1).Return(createTimeline("QA", AdoSucceededResult), nil)
+
+	result, err := uc.filterRuns(runs, UpdateFieldsParams{Stage: &StageParams{Stage: "QA"}}, nil, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{runs[0], runs[3]}, result)
+}
+
+func TestFilterRuns_ShouldReturnError_OnUnreadableTimeline(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.13", 2),
+		createPipelineRun("refs/heads/main", "25.4.12", 1),
+	}
+
+	mockRepo.On("GetRunTimeline", 2).Return(createTimeline("QA", AdoSucceededResult), nil)
+	mockRepo.On("GetRunTimeline", 1).Return(nil, errors.New("error"))
+
+	_, err := uc.filterRuns(runs, UpdateFieldsParams{Stage: &StageParams{Stage: "QA"}}, nil, nil)
+
+	assert.EqualError(t, err, "timeline of run 1: error")
+}
+
+func TestFilterRuns_ShouldStopReadingTimelines_OnceRunsAreFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{createPipelineRun("refs/heads/feature", "25.4.20", 20)}
+	for id := 19; id > 0; id-- {
+		runs = append(runs, createPipelineRun("refs/heads/main", "25.4."+strconv.Itoa(id), id))
+	}
+	mockRepo.On("GetRunTimeline", mock.Anything).Return(createTimeline("QA", AdoSucceededResult), nil)
+
+	found := uc.runsToUpdateFound("repo-id", ExactBranch("refs/heads/main"), []BaselineStrategy{sameRefBaseline{}})
+	result, err := uc.filterRuns(runs, UpdateFieldsParams{Stage: &StageParams{Stage: "QA"}}, ExactBranch("refs/heads/main"), found)
+
+	assert.Nil(t, err)
+	assert.Equal(t, runs[1:1+maxTimelineRequests], result)
+	mockRepo.AssertNotCalled(t, "GetRunTimeline", 20)
+	mockRepo.AssertNumberOfCalls(t, "GetRunTimeline", maxTimelineRequests)
+}
+
+func TestFilterRuns_ShouldKeepRunsDeployedToEnvironment(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	runs := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.13", 3),
+		createPipelineRun("refs/heads/main", "25.4.12", 2),
+		createPipelineRun("refs/heads/main", "25.4.11", 1),
+	}
+
+	mockRepo.On("GetEnvironmentByName", "QA").Return(model.Environment{Id: 5, Name: "QA"}, nil)
+	mockRepo.On("GetEnvironmentDeploymentRecords", 5).Return([]model.EnvironmentDeploymentRecord{
+		createDeploymentRecord(3, 7, "deploy_qa", "failed"),
+		createDeploymentRecord(2, 7, "deploy_qa", AdoSucceededResult),
+		createDeploymentRecord(1, 8, "deploy_qa", AdoSucceededResult),
+		createDeploymentRecord(1, 7, "smoke", AdoSucceededResult),
+	}, nil)
+
+	result, err := uc.filterRuns(runs, UpdateFieldsParams{PipelineId: 7, Stage: &StageParams{Stage: "deploy_qa", Environment: "QA"}}, nil, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PipelineRuns{runs[1]}, result)
+}
+
+func TestFilterRuns_ShouldReturnErrorOnUnknownEnvironment(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetEnvironmentByName", "QA").Return(nil, errors.New("not found"))
+
+	_, err := uc.filterRuns([]model.PipelineRuns{}, UpdateFieldsParams{Stage: &StageParams{Environment: "QA"}}, nil, nil)
+
+	assert.NotNil(t, err)
+}
+
+func TestParseEnvironmentField(t *testing.T) {
+	field, err := ParseEnvironmentField("QA=Custom.DeployedToQA")
+	assert.Nil(t, err)
+	assert.Equal(t, EnvironmentField{Environment: "QA", FieldName: "/fields/Custom.DeployedToQA"}, field)
+
+	field, err = ParseEnvironmentField("Prod=/fields/Custom.DeployedToProd")
+	assert.Nil(t, err)
+	assert.Equal(t, EnvironmentField{Environment: "Prod", FieldName: "/fields/Custom.DeployedToProd"}, field)
+
+	_, err = ParseEnvironmentField("Custom.DeployedToQA")
+	assert.ErrorIs(t, err, ErrInvalidEnvironmentField)
+}
+
+func TestUpdateFieldsByEnvironments_ShouldUpdateEachEnvironment(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	mockRepo.On("GetPipelineRuns", 7).Return([]model.PipelineRuns{createPipelineRun("refs/heads/main", "25.4.13", 1)}, nil)
+	mockRepo.On("GetEnvironmentByName", "QA").Return(nil, errors.New("QA not found"))
+	mockRepo.On("GetEnvironmentByName", "Prod").Return(nil, errors.New("Prod not found"))
+
+	err := uc.UpdateFieldsByEnvironments(UpdateFieldsParams{PipelineId: 7}, nil, []EnvironmentField{
+		{Environment: "QA", FieldName: "/fields/Custom.DeployedToQA"},
+		{Environment: "Prod", FieldName: "/fields/Custom.DeployedToProd"},
+	})
+
+	assert.ErrorContains(t, err, "environment QA: QA not found")
+	assert.ErrorContains(t, err, "environment Prod: Prod not found")
+}
+
+func TestRunStateKey_ShouldSeparateEnvironments(t *testing.T) {
+	assert.Equal(t, "7-refs%2Fheads%2Fmain", runStateKey(UpdateFieldsParams{PipelineId: 7, Stage: &StageParams{}}, "refs/heads/main"))
+	assert.Equal(t, "7-refs%2Fheads%2Fmain-environment-QA", runStateKey(UpdateFieldsParams{PipelineId: 7, Stage: &StageParams{Environment: "QA"}}, "refs/heads/main"))
+	assert.Equal(t, "7-refs%2Fheads%2Fmain-stage-deploy_qa", runStateKey(UpdateFieldsParams{PipelineId: 7, Stage: &StageParams{Stage: "deploy_qa"}}, "refs/heads/main"))
+}
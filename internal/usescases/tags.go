@@ -0,0 +1,79 @@
+package usescases
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/Damien-Venant/prev-updater/pkg/utils"
+)
+
+const (
+	AdoTagsPath      string = "/fields/" + AdoTagsFieldName
+	AdoTagsSeparator string = "; "
+)
+
+type TagParams struct {
+	// Prefix is prepended to the version, e.g. "v:" gives the tag "v:25.4.13"
+	Prefix string
+	// RemoveOlder removes the tags starting with Prefix whose version is lower than the new one
+	RemoveOlder bool
+}
+
+// updateVersionTags adds the version tag to every work item which doesn't have it yet
+func (u *AdoUsesCases) updateVersionTags(workItems []model.WorkItem, version string, param TagParams) error {
+	var errMap error = nil
+	versionTag := param.Prefix + version
+	for _, workItem := range workItems {
+		if workItem.Id == 0 {
+			continue
+		}
+		tags := splitTags(utils.Coalesce[string](workItem.Fields[AdoTagsFieldName], ""))
+		newTags := addVersionTag(tags, versionTag, param)
+		if slices.Equal(tags, newTags) {
+			continue
+		}
+		if err := u.updateFields(strconv.Itoa(workItem.Id), strings.Join(newTags, AdoTagsSeparator), AdoTagsPath); err != nil {
+			errMap = errors.Join(errMap, err)
+		}
+	}
+	return errMap
+}
+
+// addVersionTag adds versionTag to tags, the tags and the prefix are compared ignoring the case like ADO does
+// With RemoveOlder, only the versions lower than the new one are removed, so a rerun of an older run keeps the newer tags
+func addVersionTag(tags []string, versionTag string, param TagParams) []string {
+	result := make([]string, 0, len(tags)+1)
+	version := newVersion(versionTag[len(param.Prefix):])
+	found := false
+	for _, tag := range tags {
+		if strings.EqualFold(tag, versionTag) {
+			found = true
+		} else if param.RemoveOlder && hasTagPrefix(tag, param.Prefix) && newVersion(tag[len(param.Prefix):]).isSmallerThan(version) == 1 {
+			continue
+		}
+		result = append(result, tag)
+	}
+	if !found {
+		result = append(result, versionTag)
+	}
+	return result
+}
+
+// hasTagPrefix tells if tag starts with a non empty prefix, ignoring the case
+func hasTagPrefix(tag string, prefix string) bool {
+	return prefix != "" && len(tag) >= len(prefix) && strings.EqualFold(tag[:len(prefix)], prefix)
+}
+
+// splitTags splits the System.Tags value, whose tags are separated by "; "
+func splitTags(tags string) []string {
+	result := []string{}
+	for _, tag := range strings.Split(tags, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package usescases
+
+import (
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     string
+		expected []string
+	}{
+		{name: "Empty", tags: "", expected: []string{}},
+		{name: "Single", tags: "front", expected: []string{"front"}},
+		{name: "AdoSeparator", tags: "front; v:25.4.1; back", expected: []string{"front", "v:25.4.1", "back"}},
+		{name: "NoSpace", tags: "front;back;", expected: []string{"front", "back"}},
+	}
+
+	for _, test := range tests {
+		t.Run("TestSplitTags_"+test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, splitTags(test.tags))
+		})
+	}
+}
+
+func TestAddVersionTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		param    TagParams
+		expected []string
+	}{
+		{
+			name:     "AddTag",
+			tags:     []string{"front"},
+			param:    TagParams{Prefix: "v:"},
+			expected: []string{"front", "v:25.4.13"},
+		},
+		{
+			name:     "KeepOlderTags",
+			tags:     []string{"v:25.4.1", "front"},
+			param:    TagParams{Prefix: "v:"},
+			expected: []string{"v:25.4.1", "front", "v:25.4.13"},
+		},
+		{
+			name:     "RemoveOlderTags",
+			tags:     []string{"v:25.4.1", "front", "v:25.3.0"},
+			param:    TagParams{Prefix: "v:", RemoveOlder: true},
+			expected: []string{"front", "v:25.4.13"},
+		},
+		{
+			name:     "KeepNewerTags",
+			tags:     []string{"v:25.5.0", "v:25.4.1", "front"},
+			param:    TagParams{Prefix: "v:", RemoveOlder: true},
+			expected: []string{"v:25.5.0", "front", "v:25.4.13"},
+		},
+		{
+			name:     "RemoveOlderIgnoresPrefixCase",
+			tags:     []string{"V:25.4.1", "front"},
+			param:    TagParams{Prefix: "v:", RemoveOlder: true},
+			expected: []string{"front", "v:25.4.13"},
+		},
+		{
+			name:     "AlreadyTagged",
+			tags:     []string{"V:25.4.13", "front"},
+			param:    TagParams{Prefix: "v:", RemoveOlder: true},
+			expected: []string{"V:25.4.13", "front"},
+		},
+		{
+			name:     "RemoveOlderWithoutPrefixKeepsTags",
+			tags:     []string{"front"},
+			param:    TagParams{RemoveOlder: true},
+			expected: []string{"front", "25.4.13"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("TestAddVersionTag_"+test.name, func(t *testing.T) {
+			result := addVersionTag(test.tags, test.param.Prefix+"25.4.13", test.param)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestUpdateVersionTags_ShouldOnlyUpdateChangedWorkItems(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+
+	workItems := []model.WorkItem{
+		createWorkItem(1, map[string]interface{}{AdoTagsFieldName: "front; v:25.4.1"}),
+		createWorkItem(2, map[string]interface{}{AdoTagsFieldName: "v:25.4.13"}),
+		createWorkItem(3, map[string]interface{}{}),
+	}
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.updateVersionTags(workItems, "25.4.13", TagParams{Prefix: "v:", RemoveOlder: true})
+
+	assert.Nil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "UpdateWorkitemField", 2)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "1", model.OperationFields{Op: "add", Path: AdoTagsPath, Value: "front; v:25.4.13"})
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "3", model.OperationFields{Op: "add", Path: AdoTagsPath, Value: "v:25.4.13"})
+}
+
+func TestUpdateFieldsForRuns_ShouldOnlyTagUpdatedWorkItems(t *testing.T) {
+	mockRepo := new(MockUpdateRepository)
+	uc := AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createPipelineRun("refs/heads/main", "25.4.13", 2),
+		createPipelineRun("refs/heads/main", "25.4.12", 1),
+	}
+	mockRepo.On("GetBuildWorkItem", 1, 2).Return([]model.BuildWorkItems{{Id: "1"}, {Id: "2"}}, nil)
+	mockRepo.On("GetBuildChanges", 1, 2).Return([]model.BuildChanges{}, nil)
+	mockRepo.On("GetWorkItem", "1").Return(createWorkItem(1, map[string]interface{}{"Custom": ""}), nil)
+	mockRepo.On("GetWorkItem", "2").Return(createWorkItem(2, map[string]interface{}{"Custom": "25.4.1", AdoTagsFieldName: "v:25.4.1"}), nil)
+	mockRepo.On("UpdateWorkitemField", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.updateFieldsForRuns(builds, UpdateFieldsParams{FieldName: "/fields/Custom", Tags: &TagParams{Prefix: "v:", RemoveOlder: true}})
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "UpdateWorkitemField", "1", model.OperationFields{Op: "add", Path: AdoTagsPath, Value: "v:25.4.13"})
+	mockRepo.AssertNotCalled(t, "UpdateWorkitemField", "2", mock.MatchedBy(func(operation model.OperationFields) bool {
+		return operation.Path == AdoTagsPath
+	}))
+}
@@ -3,8 +3,10 @@ package usescases
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Damien-Venant/prev-updater/internal/model"
 	"github.com/Damien-Venant/prev-updater/pkg/queryslice"
@@ -22,22 +24,48 @@ const (
 type AdoRepository interface {
 	GetPipelineRuns(pipelineId int) ([]model.PipelineRuns, error)
 	GetPipelineRun(pipelineId, runId int) (*model.PipelineRuns, error)
+	GetBuildsByIds(buildIds []int) ([]model.Build, error)
 	GetBuildWorkItem(fromBuildId, toBuildId int) ([]model.BuildWorkItems, error)
+	GetBuildChanges(fromBuildId, toBuildId int) ([]model.BuildChanges, error)
 	GetWorkItem(workItemId string) (*model.WorkItem, error)
+	GetWorkItemWithRelations(workItemId string) (*model.WorkItem, error)
 	GetRepositoryById(uuid string) (*model.Repository, error)
+	GetMergeBases(repositoryId, commitId, otherCommitId string) ([]model.GitCommitRef, error)
+	GetCommitsBetween(repositoryId, fromCommitId, toCommitId string) ([]model.GitCommitRef, error)
+	GetCommitChanges(repositoryId, commitId string) ([]model.GitChange, error)
+	GetPullRequest(pullRequestId int) (*model.PullRequest, error)
+	GetPullRequests(repositoryId string, targetRefName string, minTime, maxTime time.Time) ([]model.PullRequest, error)
+	GetPullRequestWorkItems(repositoryId string, pullRequestId int) ([]model.BuildWorkItems, error)
+	GetRunTimeline(runId int) (*model.Timeline, error)
+	GetEnvironmentByName(name string) (*model.Environment, error)
+	GetEnvironmentDeploymentRecords(environmentId int) ([]model.EnvironmentDeploymentRecord, error)
 	UpdateWorkitemField(workItemId string, operation model.OperationFields) error
+	UpdateWorkitemFields(workItemId string, operations []model.OperationFields) error
+	GetWorkItemComments(workItemId string) ([]model.WorkItemComment, error)
+	AddWorkItemComment(workItemId string, text string) error
 }
 
-type N8nRepository interface {
-	PostWebhook(data model.N8nResult) error
+// Notifier is a sink where the release event is delivered
+type Notifier interface {
+	Name() string
+	Notify(data model.N8nResult) error
 }
 
 type (
 	Version      [4]int
 	AdoUsesCases struct {
-		N8nRepo    N8nRepository
+		Notifiers  []Notifier
+		Outbox     Outbox
+		State      RunState
 		Repository AdoRepository
-		Logger     *zerolog.Logger
+		// SourceHosts read the repositories hosted outside of Azure Repos, by repository type
+		SourceHosts map[string]SourceHost
+		Logger      *zerolog.Logger
+	}
+
+	NotificationResult struct {
+		Sink string
+		Err  error
 	}
 
 	UpdateFieldsParams struct {
@@ -45,12 +73,54 @@ type (
 		RepositoryId string
 		FieldName    string
 		BranchName   string
+		// BranchMatch is how BranchName is matched: exact, glob or regex, exact when it is empty
+		BranchMatch string
+		// ParentType is the work item type (e.g. "User Story") that also receives the version of its children
+		ParentType string
+		// RollupTypes are the work item types (e.g. Feature, Epic) whose version is rolled up from their children
+		RollupTypes []string
+		// Tags adds the version as a work item tag when it is set
+		Tags *TagParams
+		// Mappings are the templated fields set on every work item of the run
+		Mappings []FieldMapping
+		// IntegrationBuild is the codec of the versions history field
+		IntegrationBuild IntegrationBuildCodec
+		// Comment adds a discussion comment on each updated work item when it is set
+		Comment *CommentParams
+		// PayloadFields adds work item fields to the notifications, by payload key
+		PayloadFields map[string]string
+		// Discovery adds the work items mentioned in the commits but not linked to them when it is set
+		Discovery *DiscoveryParams
+		// WorkItemSources are where the work items of the run are found: build, pull-requests or both
+		// Only the work items linked to the build are used when it is empty
+		WorkItemSources []string
+		// Stage keeps only the runs that passed a stage or were deployed to an environment, instead of the completed runs
+		Stage *StageParams
+		// RunFilter restricts the runs by result, reason and ref, any run is used when it is empty
+		RunFilter RunFilter
+		// Range uses an explicit range of runs instead of the last two runs when it is set
+		Range *RunRange
+		// Baselines are the names of the strategies finding the baseline run, tried in order
+		// The previous run on the same ref then the last run on the default branch are used when it is empty
+		Baselines []string
+		// BaselineTag is the build tag of the baseline run for the tag strategy
+		BaselineTag string
+		// DefaultBranch is the ref of the default branch of the repository, it is read from the repository when it is empty
+		DefaultBranch string
+		// PathFilter keeps only the work items of the commits touching its paths when it is set
+		// It applies to the pipeline's own repository, the work items of the other repositories and of the upstream pipelines are kept
+		PathFilter *PathFilter
+		// UpstreamDepth follows the pipelines consumed through resources.pipelines up to this depth, 0 to ignore them
+		UpstreamDepth int
+		// CatchUp processes every run since the last processed run, kept in the run state, instead of only the last run
+		CatchUp bool
 	}
 )
 
-func NewAdoUsesCases(adoRepository AdoRepository, N8nRepo N8nRepository, logger *zerolog.Logger) *AdoUsesCases {
+func NewAdoUsesCases(adoRepository AdoRepository, notifiers []Notifier, outbox Outbox, logger *zerolog.Logger) *AdoUsesCases {
 	return &AdoUsesCases{
-		N8nRepo:    N8nRepo,
+		Notifiers:  notifiers,
+		Outbox:     outbox,
 		Repository: adoRepository,
 		Logger:     logger,
 	}
@@ -61,29 +131,107 @@ func (u *AdoUsesCases) UpdateFieldsByPipelineId(pipelineId int) error {
 }
 
 func (u *AdoUsesCases) UpdateFieldsByLastRuns(param UpdateFieldsParams) error {
-	adoRep := u.Repository
-	result, err := adoRep.GetPipelineRuns(param.PipelineId)
-	if err != nil {
-		return err
-	} else if len(result) == 0 {
-		return nil
+	if param.FieldName == "" && param.Tags == nil && len(param.Mappings) == 0 {
+		return ErrNoUpdateTarget
 	}
-
-	builds, err := u.getRunsToUpdate(result, param.RepositoryId, param.PipelineId, param.BranchName)
+	for _, source := range param.WorkItemSources {
+		if source != WorkItemSourceBuild && source != WorkItemSourcePullRequests {
+			return fmt.Errorf("%w: %s", ErrInvalidWorkItemSource, source)
+		}
+	}
+	branch, err := param.branchMatcher()
 	if err != nil {
 		return err
 	}
+	if param.CatchUp {
+		return u.catchUp(param, branch)
+	}
+	var builds []model.PipelineRuns
+	if param.Range != nil && param.Range.isSet() {
+		if builds, err = u.getRunsInRange(param, branch); err != nil {
+			return err
+		}
+	} else {
+		adoRep := u.Repository
+		result, err := adoRep.GetPipelineRuns(param.PipelineId)
+		if err != nil {
+			return err
+		}
+		strategies, err := u.baselineStrategies(param)
+		if err != nil {
+			return err
+		}
+		found := u.runsToUpdateFound(param.RepositoryId, branch, strategies)
+		if result, err = u.filterRuns(result, param, branch, found); err != nil {
+			return err
+		} else if len(result) == 0 {
+			return nil
+		}
+		if builds, err = u.getRunsToUpdate(result, param.RepositoryId, param.PipelineId, branch, strategies...); err != nil {
+			return err
+		}
+	}
+	return u.updateFieldsForRuns(builds, param)
+}
+
+// updateFieldsForRuns updates the work items integrated by the run builds[0] since its baseline builds[1]
+func (u *AdoUsesCases) updateFieldsForRuns(builds []model.PipelineRuns, param UpdateFieldsParams) error {
+	var err error
 	lastBuild := builds[0]
+	repositoryId := aliasRepositoryId(SelfRepositoryAlias, selfRepository(lastBuild), param.RepositoryId)
+	if repositoryId == "" && (slices.Contains(param.WorkItemSources, WorkItemSourcePullRequests) || (param.Discovery != nil && param.Discovery.LinkMissing)) {
+		return ErrUnknownRepository
+	}
 
-	workItems, err := u.getAllWorkItems(builds)
-	if err != nil {
-		return err
+	workItems := []model.WorkItem{}
+	if len(param.WorkItemSources) == 0 || slices.Contains(param.WorkItemSources, WorkItemSourceBuild) {
+		if workItems, err = u.getAllWorkItems(builds); err != nil {
+			return err
+		}
+	}
+	commits := u.getCommits(builds)
+	if slices.Contains(param.WorkItemSources, WorkItemSourcePullRequests) {
+		pullRequestWorkItems, err := u.getPullRequestWorkItems(builds, repositoryId, commits)
+		if err != nil {
+			return err
+		}
+		workItems = appendMissingWorkItems(workItems, pullRequestWorkItems)
+	}
+	var pathWorkItemIds map[int]bool = nil
+	if param.PathFilter != nil {
+		if pathWorkItemIds, err = u.getPathWorkItemIds(builds, param.RepositoryId, *param.PathFilter, param.Discovery != nil && param.Discovery.HashMentions); err != nil {
+			return err
+		}
+		workItems = keepWorkItems(workItems, pathWorkItemIds)
+	}
+	repositories := map[int][]string{}
+	if isMultiRepository(lastBuild) {
+		repositories = u.getRepositoryWorkItems(builds, param.RepositoryId)
+		if param.PathFilter != nil {
+			repositories = keepPathRepositories(repositories, pathWorkItemIds)
+		}
+		workItems = u.appendRepositoryWorkItems(workItems, repositories)
+	}
+	if param.UpstreamDepth > 0 {
+		workItems = u.appendWorkItemsById(workItems, u.getUpstreamWorkItemIds(builds, param.UpstreamDepth))
+	}
+	if param.Discovery != nil {
+		discovered := u.discoverWorkItems(workItems, commits, repositoryId, *param.Discovery)
+		if param.PathFilter != nil {
+			discovered = keepWorkItems(discovered, pathWorkItemIds)
+		}
+		workItems = append(workItems, discovered...)
+	}
+	if param.ParentType != "" {
+		workItems = append(workItems, u.getParentWorkItems(workItems, param.ParentType)...)
 	}
 
 	versionName := lastBuild.Name
-	tabFieldName := strings.Split(param.FieldName, "/")
-	fieldName := tabFieldName[len(tabFieldName)-1]
-	workItemsToUpdatePrev := u.getAllWorkItemsToUpdatePrev(workItems, builds[0].Name, fieldName)
+	workItemsToUpdatePrev := []model.WorkItem{}
+	if param.FieldName != "" {
+		fieldName := fieldNameFromPath(param.FieldName)
+		workItemsToUpdatePrev = u.getAllWorkItemsToUpdatePrev(workItems, builds[0].Name, fieldName)
+	}
 
 	if len(workItemsToUpdatePrev) > 0 {
 		var errMap error = nil
@@ -98,54 +246,88 @@ func (u *AdoUsesCases) UpdateFieldsByLastRuns(param UpdateFieldsParams) error {
 		}
 	}
 
-	if len(workItems) > 0 {
-		u.updateAdoIntegrationBuild(workItems, versionName)
-		err := u.sendDataToN8N(workItems, versionName, param.BranchName)
-		return err
+	if param.Comment != nil {
+		toComment := workItemsToUpdatePrev
+		if param.FieldName == "" {
+			toComment = workItems
+		}
+		if err := u.addVersionComments(toComment, CommentTemplateData{
+			Version: versionName,
+			Branch:  param.runBranch(lastBuild),
+			RunUrl:  lastBuild.Links.Web.Href,
+			Run:     lastBuild,
+			Commits: commits,
+
+			repositories: repositories,
+		}, *param.Comment); err != nil && u.Logger != nil {
+			u.Logger.Warn().Err(err).Msg("addVersionComments")
+		}
 	}
-	return nil
-}
 
-// getRunsToUpdate is used to return last build and N-1 last build
-// It's return a array where the first index is last build and second index is N-1 last build
-// If the build have not previous build the N-1 last build is last build on defaultBranch
-func (u *AdoUsesCases) getRunsToUpdate(builds []model.PipelineRuns, repositoryId string, pipelineId int, branchName string) ([]model.PipelineRuns, error) {
-	var lastBuild model.PipelineRuns
-	adoRep := u.Repository
-	defaultRefName, err := adoRep.GetRepositoryById(repositoryId)
-	if err != nil {
-		return nil, err
+	if param.Tags != nil {
+		toTag := workItemsToUpdatePrev
+		if param.FieldName == "" {
+			toTag = workItems
+		}
+		if err := u.updateVersionTags(toTag, versionName, *param.Tags); err != nil {
+			return err
+		}
 	}
-	builds = queryslice.Filter(builds, func(pre model.PipelineRuns) bool {
-		return pre.State == "completed"
-	})
 
-	index := 0
-	if branchName == "" {
-		lastBuild = builds[0]
-	} else {
-		index = queryslice.FindIndex(builds, func(pre model.PipelineRuns) bool {
-			return strings.Contains(pre.Resources.Repositories.Self.RefName, branchName)
-		})
-		if index < 0 {
-			return []model.PipelineRuns{}, ErrBranchNameNotExist
+	if len(param.Mappings) > 0 {
+		if err := u.applyFieldMappings(workItems, param.Mappings, FieldTemplateData{
+			Version:  versionName,
+			Branch:   param.runBranch(lastBuild),
+			Run:      builds[0],
+			Baseline: builds[1],
+			Commits:  commits,
+		}); err != nil {
+			return err
 		}
-		lastBuild = builds[index]
 	}
 
-	buildsOnSameRef := queryslice.Filter(builds, func(pre model.PipelineRuns) bool {
-		return pre.Resources.Repositories.Self.RefName == lastBuild.Resources.Repositories.Self.RefName
-	})
+	if len(param.RollupTypes) > 0 && param.FieldName != "" {
+		if err := u.rollupParents(workItems, param.FieldName, param.RollupTypes); err != nil {
+			return err
+		}
+	}
 
-	if len(buildsOnSameRef) > 1 {
-		return []model.PipelineRuns{buildsOnSameRef[0], buildsOnSameRef[1]}, nil
+	if len(workItems) > 0 {
+		codec := param.IntegrationBuild
+		if codec.FieldName == "" {
+			codec = DefaultIntegrationBuildCodec()
+		}
+		if err := u.updateAdoIntegrationBuild(workItems, versionName, codec); err != nil && u.Logger != nil {
+			u.Logger.Warn().Err(err).Msg("updateAdoIntegrationBuild")
+		}
+		u.notify(newReleaseEvent(workItems, commits, repositories, builds, param, codec))
 	}
+	return nil
+}
 
-	lastBuildOnDefaultRefName := queryslice.Filter(builds[index:], func(pre model.PipelineRuns) bool {
-		return pre.Resources.Repositories.Self.RefName == defaultRefName.DefaultBranch
-	})[0]
+// getRunsToUpdate is used to return last build and its baseline
+// It's return a array where the first index is last build and second index is the baseline
+// The baseline is found by the first strategy that finds one, by default the previous run on the same ref
+// then the last run on defaultBranch
+// The builds are expected to be already filtered by filterRuns
+func (u *AdoUsesCases) getRunsToUpdate(builds []model.PipelineRuns, repositoryId string, pipelineId int, branch BranchMatcher, strategies ...BaselineStrategy) ([]model.PipelineRuns, error) {
+	index := findBranchRun(builds, branch)
+	if index < 0 {
+		return []model.PipelineRuns{}, ErrBranchNameNotExist
+	}
+	if len(strategies) == 0 {
+		var err error
+		if strategies, err = u.defaultBaselineStrategies(repositoryId, builds[index]); err != nil {
+			return nil, err
+		}
+	}
 
-	return []model.PipelineRuns{builds[index], lastBuildOnDefaultRefName}, nil
+	lastBuild := builds[index]
+	baseline, err := findBaseline(strategies, lastBuild, builds[index+1:])
+	if err != nil {
+		return nil, err
+	}
+	return []model.PipelineRuns{lastBuild, *baseline}, nil
 }
 
 func (u *AdoUsesCases) getAllWorkItems(builds []model.PipelineRuns) ([]model.WorkItem, error) {
@@ -155,15 +337,19 @@ func (u *AdoUsesCases) getAllWorkItems(builds []model.PipelineRuns) ([]model.Wor
 		return []model.WorkItem{}, err
 	}
 
-	workItems := queryslice.TransformParallel(buildWorkItems, func(val model.BuildWorkItems, _ int) model.WorkItem {
+	return u.fetchWorkItems(buildWorkItems), nil
+}
+
+// fetchWorkItems gets the work items of the references, a work item that can't be read is left empty
+func (u *AdoUsesCases) fetchWorkItems(references []model.BuildWorkItems) []model.WorkItem {
+	adoRep := u.Repository
+	return queryslice.TransformParallel(references, func(val model.BuildWorkItems, _ int) model.WorkItem {
 		workItem, err := adoRep.GetWorkItem(val.Id)
 		if err != nil {
 			return model.WorkItem{}
 		}
 		return *workItem
 	})
-
-	return workItems, nil
 }
 
 func (u *AdoUsesCases) getAllWorkItemsToUpdatePrev(workItems []model.WorkItem, version, fieldName string) []model.WorkItem {
@@ -180,23 +366,6 @@ func (u *AdoUsesCases) getAllWorkItemsToUpdatePrev(workItems []model.WorkItem, v
 	return workItems
 }
 
-func (u *AdoUsesCases) updateAdoIntegrationBuild(workItems []model.WorkItem, version string) error {
-	var errMap error = nil
-	for _, workItem := range workItems {
-		var concatenateVersion string
-		val, _ := workItem.Fields[AdoIntegrationBuildFieldName].(string)
-		if val != "" && !strings.Contains(val, version) {
-			concatenateVersion = fmt.Sprintf("%s | %s", val, version)
-		} else {
-			concatenateVersion = version
-		}
-		if err := u.updateFields(strconv.FormatInt(int64(workItem.Id), 10), concatenateVersion, AdoIntegrationPath); err != nil {
-			errMap = errors.Join(errMap)
-		}
-	}
-	return errMap
-}
-
 func (u *AdoUsesCases) updateFields(woritemId, name, path string) error {
 	repo := u.Repository
 	modelToUpdload := model.OperationFields{
@@ -208,12 +377,59 @@ func (u *AdoUsesCases) updateFields(woritemId, name, path string) error {
 	return repo.UpdateWorkitemField(woritemId, modelToUpdload)
 }
 
-func (u *AdoUsesCases) sendDataToN8N(workitems []model.WorkItem, version string, sourceBranch string) error {
-	data := WorkItemToN8NResult(workitems)
-	data.Version = version
-	data.SourceBranch = sourceBranch
+// UpdateFieldsByBranches updates the fields for the last run of each branch, or of any branch without branches
+// Every branch is updated even when another one fails
+func (u *AdoUsesCases) UpdateFieldsByBranches(param UpdateFieldsParams, branches []string) error {
+	if len(branches) == 0 {
+		return u.UpdateFieldsByLastRuns(param)
+	}
+	var errMap error = nil
+	for _, branch := range branches {
+		param.BranchName = branch
+		if err := u.UpdateFieldsByLastRuns(param); err != nil {
+			errMap = errors.Join(errMap, fmt.Errorf("branch %s: %w", branch, err))
+		}
+	}
+	return errMap
+}
+
+// notify delivers the release event to every notifier, through the outbox when there is one
+// A failed delivery is reported but doesn't fail the update of the fields
+func (u *AdoUsesCases) notify(data model.N8nResult) []NotificationResult {
+	results := make([]NotificationResult, 0, len(u.Notifiers))
+	for _, notifier := range u.Notifiers {
+		entry := u.storeInOutbox(notifier.Name(), data)
+		result := NotificationResult{
+			Sink: notifier.Name(),
+			Err:  notifier.Notify(data),
+		}
+		u.markOutboxEntry(entry, result.Err)
+		u.logNotificationResult(result)
+		results = append(results, result)
+	}
+	return results
+}
 
-	return u.N8nRepo.PostWebhook(data)
+func (u *AdoUsesCases) logNotificationResult(result NotificationResult) {
+	if u.Logger == nil {
+		return
+	}
+	if result.Err != nil {
+		u.Logger.Error().
+			Err(result.Err).
+			Str("sink", result.Sink).
+			Msg("Notification not delivered")
+		return
+	}
+	u.Logger.Info().
+		Str("sink", result.Sink).
+		Msg("Notification delivered")
+}
+
+// fieldNameFromPath returns the field reference name of a patch path like /fields/Custom.Version
+func fieldNameFromPath(path string) string {
+	tabFieldName := strings.Split(path, "/")
+	return tabFieldName[len(tabFieldName)-1]
 }
 
 func (actual Version) isSmallerThan(targetVersion Version) int {
@@ -235,13 +451,16 @@ func newVersion(version string) Version {
 	result := Version{}
 	res := strings.Split(version, ".")
 	for index, val := range res {
+		if index >= len(result) {
+			break
+		}
 		resultConv, _ := strconv.ParseInt(val, 10, 32)
 		result[index] = int(resultConv)
 	}
 	return result
 }
 
-func WorkItemToN8NResult(workitems []model.WorkItem) model.N8nResult {
+func WorkItemToN8NResult(workitems []model.WorkItem, codec IntegrationBuildCodec, payloadFields map[string]string) model.N8nResult {
 	wItems := make([]model.N8NWorkItems, len(workitems))
 
 	for index, val := range workitems {
@@ -249,17 +468,23 @@ func WorkItemToN8NResult(workitems []model.WorkItem) model.N8nResult {
 		integrations := []string{}
 
 		if tagsStr := utils.Coalesce[string](val.Fields[AdoTagsFieldName], ""); tagsStr != "" {
-			tags = strings.Split(tagsStr, ";")
+			tags = splitTags(tagsStr)
 		}
-		if integrationBuild := utils.Coalesce[string](val.Fields[AdoIntegrationBuildFieldName], ""); integrationBuild != "" {
-			integrations = strings.Split(integrationBuild, "|")
+		if integrationBuild := utils.Coalesce[string](val.Fields[codec.FieldName], ""); integrationBuild != "" {
+			integrations = codec.Decode(integrationBuild)
 		}
 
 		wItems[index] = model.N8NWorkItems{
 			Id:               val.Id,
 			Title:            utils.Coalesce[string](val.Fields[AdoTitleFieldName], ""),
+			Type:             workItemType(val),
+			State:            utils.Coalesce[string](val.Fields[AdoStateFieldName], ""),
+			AssignedTo:       assigneeName(val),
+			AreaPath:         utils.Coalesce[string](val.Fields[AdoAreaPathFieldName], ""),
+			Url:              workItemWebUrl(val),
 			Tags:             tags,
 			IntegrationBuild: integrations,
+			Fields:           mapPayloadFields(val, payloadFields),
 		}
 	}
 
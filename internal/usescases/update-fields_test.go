@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Damien-Venant/prev-updater/internal/model"
 	"github.com/gkampitakis/go-snaps/snaps"
@@ -36,13 +37,43 @@ func (m *MockRepository) GetPipelineRuns(pipelineId int) ([]model.PipelineRuns,
 	return val, args.Error(1)
 }
 func (m *MockRepository) GetPipelineRun(pipelineId, runId int) (*model.PipelineRuns, error) {
-	return nil, nil
+	args := m.Called(pipelineId, runId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	val, _ := args.Get(0).(model.PipelineRuns)
+	return &val, args.Error(1)
+}
+func (m *MockRepository) GetBuildsByIds(buildIds []int) ([]model.Build, error) {
+	args := m.Called(buildIds)
+	val, _ := args.Get(0).([]model.Build)
+	return val, args.Error(1)
+}
+func (m *MockRepository) GetMergeBases(repositoryId, commitId, otherCommitId string) ([]model.GitCommitRef, error) {
+	args := m.Called(repositoryId, commitId, otherCommitId)
+	val, _ := args.Get(0).([]model.GitCommitRef)
+	return val, args.Error(1)
+}
+func (m *MockRepository) GetCommitsBetween(repositoryId, fromCommitId, toCommitId string) ([]model.GitCommitRef, error) {
+	args := m.Called(repositoryId, fromCommitId, toCommitId)
+	val, _ := args.Get(0).([]model.GitCommitRef)
+	return val, args.Error(1)
+}
+func (m *MockRepository) GetCommitChanges(repositoryId, commitId string) ([]model.GitChange, error) {
+	args := m.Called(repositoryId, commitId)
+	val, _ := args.Get(0).([]model.GitChange)
+	return val, args.Error(1)
 }
 func (m *MockRepository) GetBuildWorkItem(fromBuildId, toBuildId int) ([]model.BuildWorkItems, error) {
 	args := m.Called(fromBuildId, toBuildId)
 	val := args.Get(0).([]model.BuildWorkItems)
 	return val, args.Error(1)
 }
+func (m *MockRepository) GetBuildChanges(fromBuildId, toBuildId int) ([]model.BuildChanges, error) {
+	args := m.Called(fromBuildId, toBuildId)
+	val, _ := args.Get(0).([]model.BuildChanges)
+	return val, args.Error(1)
+}
 func (m *MockRepository) GetWorkItem(workItemId string) (*model.WorkItem, error) {
 	var val model.WorkItem
 	args := m.Called(workItemId)
@@ -52,11 +83,87 @@ func (m *MockRepository) GetWorkItem(workItemId string) (*model.WorkItem, error)
 	val, _ = args.Get(0).(model.WorkItem)
 	return &val, args.Error(1)
 }
+func (m *MockRepository) GetWorkItemWithRelations(workItemId string) (*model.WorkItem, error) {
+	args := m.Called(workItemId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	val, _ := args.Get(0).(model.WorkItem)
+	return &val, args.Error(1)
+}
+func (m *MockRepository) GetPullRequest(pullRequestId int) (*model.PullRequest, error) {
+	args := m.Called(pullRequestId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	val, _ := args.Get(0).(model.PullRequest)
+	return &val, args.Error(1)
+}
+func (m *MockRepository) GetPullRequests(repositoryId string, targetRefName string, minTime, maxTime time.Time) ([]model.PullRequest, error) {
+	args := m.Called(repositoryId, targetRefName, minTime, maxTime)
+	val, _ := args.Get(0).([]model.PullRequest)
+	return val, args.Error(1)
+}
+func (m *MockRepository) GetPullRequestWorkItems(repositoryId string, pullRequestId int) ([]model.BuildWorkItems, error) {
+	args := m.Called(repositoryId, pullRequestId)
+	val, _ := args.Get(0).([]model.BuildWorkItems)
+	return val, args.Error(1)
+}
+func (m *MockRepository) GetRunTimeline(runId int) (*model.Timeline, error) {
+	args := m.Called(runId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	val, _ := args.Get(0).(model.Timeline)
+	return &val, args.Error(1)
+}
+func (m *MockRepository) GetEnvironmentByName(name string) (*model.Environment, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	val, _ := args.Get(0).(model.Environment)
+	return &val, args.Error(1)
+}
+func (m *MockRepository) GetEnvironmentDeploymentRecords(environmentId int) ([]model.EnvironmentDeploymentRecord, error) {
+	args := m.Called(environmentId)
+	val, _ := args.Get(0).([]model.EnvironmentDeploymentRecord)
+	return val, args.Error(1)
+}
 func (m *MockRepository) UpdateWorkitemField(workItemId string, operation model.OperationFields) error {
 	return nil
 }
 
-func (m *MockN8N) PostWebhook(data model.N8nResult) error {
+func (m *MockRepository) UpdateWorkitemFields(workItemId string, operations []model.OperationFields) error {
+	args := m.Called(workItemId, operations)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetWorkItemComments(workItemId string) ([]model.WorkItemComment, error) {
+	args := m.Called(workItemId)
+	val, _ := args.Get(0).([]model.WorkItemComment)
+	return val, args.Error(1)
+}
+func (m *MockRepository) AddWorkItemComment(workItemId string, text string) error {
+	args := m.Called(workItemId, text)
+	return args.Error(0)
+}
+
+// MockUpdateRepository records the updates made on work items
+type MockUpdateRepository struct {
+	MockRepository
+}
+
+func (m *MockUpdateRepository) UpdateWorkitemField(workItemId string, operation model.OperationFields) error {
+	args := m.Called(workItemId, operation)
+	return args.Error(0)
+}
+
+func (m *MockN8N) Name() string {
+	return "n8n"
+}
+
+func (m *MockN8N) Notify(data model.N8nResult) error {
 	args := m.Called(data)
 	return args.Error(0)
 }
@@ -67,24 +174,9 @@ func createPipelineRun(ref string, name string, id int) model.PipelineRuns {
 		Id:    id,
 		State: "completed",
 		Name:  name,
-		Resources: &struct {
-			Repositories *struct {
-				Self struct {
-					RefName string "json:\"refName\""
-					Version string "json:\"Version\""
-				} "json:\"self\""
-			} "json:\"repositories\""
-		}{
-			Repositories: &struct {
-				Self struct {
-					RefName string "json:\"refName\""
-					Version string "json:\"Version\""
-				} "json:\"self\""
-			}{
-				Self: struct {
-					RefName string "json:\"refName\""
-					Version string "json:\"Version\""
-				}{RefName: ref},
+		Resources: &model.RunResources{
+			Repositories: map[string]model.RepositoryResource{
+				SelfRepositoryAlias: {RefName: ref},
 			},
 		},
 	}
@@ -117,7 +209,7 @@ func TestGetRunsToUpdate_TwoBuildsOnSameRef(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, builds[0], result[0])
@@ -138,7 +230,7 @@ func TestGetRunsToUpdate_OnlyOneBuildOnRef(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, builds[0], result[0]) // Last on current ref
@@ -158,7 +250,7 @@ func TestGetRunsToUpdate_DefaultBranch(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, builds[0], result[0]) // Last on default ref
@@ -178,7 +270,7 @@ func TestGetRunsToUpdate_SpecificBranchName(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "feature-1")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, ExactBranch("feature-1"))
 
 	assert.NoError(t, err)
 	assert.Equal(t, builds[1], result[0]) // Last on default ref
@@ -197,7 +289,7 @@ func TestGetRunsToUpdate_SpecificBranchNameWithOneRun(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{DefaultBranch: "refs/heads/main"}, nil)
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "feature-1")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, ExactBranch("feature-1"))
 
 	assert.NoError(t, err)
 	assert.Equal(t, builds[1], result[0]) // Last on default ref
@@ -215,7 +307,7 @@ func TestGetRunsToUpdate_RepositoryError(t *testing.T) {
 
 	mockRepo.On("GetRepositoryById", "repo-id").Return(model.Repository{}, errors.New("db error"))
 
-	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, "")
+	result, err := uc.getRunsToUpdate(builds, "repo-id", 123, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -338,7 +430,7 @@ func TestUpdateAdoIntegrationBuild_WithEmptyVersion(t *testing.T) {
 
 	_ = mockRepo.On("UpdateWorkItemFields", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := uc.updateAdoIntegrationBuild(workItems, version)
+	err := uc.updateAdoIntegrationBuild(workItems, version, DefaultIntegrationBuildCodec())
 
 	assert.Nil(t, err)
 	mockRepo.AssertNotCalled(t, "UpdatetItemFields", mock.Anything, version, mock.Anything)
@@ -356,7 +448,7 @@ func TestUpdateAdoIntegrationBuild_WithNotEmptyVerison(t *testing.T) {
 
 	_ = mockRepo.On("UpdateWorkItemFields", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := uc.updateAdoIntegrationBuild(workItems, version)
+	err := uc.updateAdoIntegrationBuild(workItems, version, DefaultIntegrationBuildCodec())
 
 	assert.Nil(t, err)
 	mockRepo.AssertNotCalled(t, "UpdatetItemFields", mock.Anything, result, mock.Anything)
@@ -374,7 +466,7 @@ func TestUpdateAdoIntegration_WithSomeVerion(t *testing.T) {
 
 	_ = mockRepo.On("UpdateWorkItemFields", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := uc.updateAdoIntegrationBuild(workItems, version)
+	err := uc.updateAdoIntegrationBuild(workItems, version, DefaultIntegrationBuildCodec())
 
 	assert.Nil(t, err)
 	mockRepo.AssertNotCalled(t, "UpdatetItemFields", mock.Anything, result, mock.Anything)
@@ -392,7 +484,7 @@ func TestUpdateAdoIntegration_AlreadyContainsVersion_ShouldNotAddIt(t *testing.T
 
 	_ = mockRepo.On("UpdateWorkItemFields", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	err := uc.updateAdoIntegrationBuild(workItems, version)
+	err := uc.updateAdoIntegrationBuild(workItems, version, DefaultIntegrationBuildCodec())
 
 	assert.Nil(t, err)
 	mockRepo.AssertNotCalled(t, "UpdatetItemFields", mock.Anything, result, mock.Anything)
@@ -432,7 +524,7 @@ func TestUpdateFieldsByLastRuns_WhenPipelineRunReturnAnError(t *testing.T) {
 func TestUpdateFieldsByLastRuns(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockN8N := new(MockN8N)
-	uc := AdoUsesCases{Repository: mockRepo, N8nRepo: mockN8N}
+	uc := AdoUsesCases{Repository: mockRepo, Notifiers: []Notifier{mockN8N}}
 
 	pipelineRuns := []model.PipelineRuns{
 		createPipelineRun("main", "25.6.5.0", 4),
@@ -452,11 +544,12 @@ func TestUpdateFieldsByLastRuns(t *testing.T) {
 	mockRepo.On("GetPipelineRuns", mock.Anything).Return(pipelineRuns, nil)
 	mockRepo.On("GetRepositoryById", mock.Anything).Return(model.Repository{Id: "1", DefaultBranch: "main", Url: ""}, nil)
 	mockRepo.On("GetBuildWorkItem", 3, 4).Return(buildWorkItems, nil)
+	mockRepo.On("GetBuildChanges", 3, 4).Return([]model.BuildChanges{}, nil)
 	for _, workItem := range workItems {
 		mockRepo.On("GetWorkItem", fmt.Sprintf("%d", workItem.Id)).Return(workItem, nil)
 	}
 	mockRepo.On("UpdateWorkItemField", mock.Anything, mock.Anything)
-	mockN8N.On("PostWebhook", mock.Anything).Return(nil)
+	mockN8N.On("Notify", mock.Anything).Return(nil)
 
 	err := uc.UpdateFieldsByLastRuns(UpdateFieldsParams{
 		PipelineId:   862,
@@ -489,6 +582,7 @@ func TestUpdateFieldsByLastRuns_ShouldReturnError_OnPipelineRuns(t *testing.T) {
 	mockRepo.On("GetPipelineRuns", mock.Anything).Return(pipelineRuns, errors.New("error"))
 	mockRepo.On("GetRepositoryById", mock.Anything).Return(model.Repository{Id: "1", DefaultBranch: "main", Url: ""}, nil)
 	mockRepo.On("GetBuildWorkItem", 3, 4).Return(buildWorkItems, nil)
+	mockRepo.On("GetBuildChanges", 3, 4).Return([]model.BuildChanges{}, nil)
 	for _, workItem := range workItems {
 		mockRepo.On("GetWorkItem", fmt.Sprintf("%d", workItem.Id)).Return(workItem, nil)
 	}
@@ -525,6 +619,7 @@ func TestUpdateFieldsByLastRuns_ShouldReturnError_OnRepositoryId(t *testing.T) {
 	mockRepo.On("GetPipelineRuns", mock.Anything).Return(pipelineRuns, nil)
 	mockRepo.On("GetRepositoryById", mock.Anything).Return(model.Repository{Id: "1", DefaultBranch: "main", Url: ""}, errors.New("error"))
 	mockRepo.On("GetBuildWorkItem", 3, 4).Return(buildWorkItems, nil)
+	mockRepo.On("GetBuildChanges", 3, 4).Return([]model.BuildChanges{}, nil)
 	for _, workItem := range workItems {
 		mockRepo.On("GetWorkItem", fmt.Sprintf("%d", workItem.Id)).Return(workItem, nil)
 	}
@@ -760,17 +855,20 @@ func TestSendToN8N(t *testing.T) {
 	}
 
 	mockN8n := new(MockN8N)
-	mockN8n.On("PostWebhook", mock.Anything).Return(nil)
+	mockN8n.On("Notify", mock.Anything).Return(nil)
 
 	for index, test := range tests {
 		name := fmt.Sprintf("TestSendToN8N_%d", index)
 		t.Run(name, func(t *testing.T) {
 			usecase := AdoUsesCases{
-				N8nRepo: mockN8n,
+				Notifiers: []Notifier{mockN8n},
 			}
-			result := usecase.sendDataToN8N(test.workItems, test.version, test.sourceBranch)
+			data := WorkItemToN8NResult(test.workItems, DefaultIntegrationBuildCodec(), nil)
+			data.Version = test.version
+			data.SourceBranch = test.sourceBranch
+			result := usecase.notify(data)
 
-			assert.Nil(t, result)
+			assert.Nil(t, result[0].Err)
 		})
 	}
 }
@@ -804,17 +902,20 @@ func TestSendToN8NWithEmptyFields(t *testing.T) {
 	}
 
 	mockN8n := new(MockN8N)
-	mockN8n.On("PostWebhook", mock.Anything).Return(nil)
+	mockN8n.On("Notify", mock.Anything).Return(nil)
 
 	for index, test := range tests {
 		name := fmt.Sprintf("TestSendToN8N_%d", index)
 		t.Run(name, func(t *testing.T) {
 			usecase := AdoUsesCases{
-				N8nRepo: mockN8n,
+				Notifiers: []Notifier{mockN8n},
 			}
-			result := usecase.sendDataToN8N(test.workItems, test.version, test.sourceBranch)
+			data := WorkItemToN8NResult(test.workItems, DefaultIntegrationBuildCodec(), nil)
+			data.Version = test.version
+			data.SourceBranch = test.sourceBranch
+			result := usecase.notify(data)
 
-			assert.Nil(t, result)
+			assert.Nil(t, result[0].Err)
 		})
 	}
 }
@@ -860,9 +961,26 @@ func TestWorkItemToN8NResult(t *testing.T) {
 			}),
 		)
 		t.Run(name, func(t *testing.T) {
-			result := WorkItemToN8NResult(test.workItems)
+			result := WorkItemToN8NResult(test.workItems, DefaultIntegrationBuildCodec(), nil)
 			jsonResult, _ := json.Marshal(result)
 			s.MatchJSON(t, jsonResult)
 		})
 	}
 }
+
+func TestNotify_ShouldReportEachSink(t *testing.T) {
+	failing := new(MockN8N)
+	failing.On("Notify", mock.Anything).Return(errors.New("error"))
+	succeeding := new(MockN8N)
+	succeeding.On("Notify", mock.Anything).Return(nil)
+
+	usecase := AdoUsesCases{
+		Notifiers: []Notifier{failing, succeeding},
+	}
+	results := usecase.notify(WorkItemToN8NResult([]model.WorkItem{createWorkItem(1, nil)}, DefaultIntegrationBuildCodec(), nil))
+
+	assert.Len(t, results, 2)
+	assert.NotNil(t, results[0].Err)
+	assert.Nil(t, results[1].Err)
+	succeeding.AssertCalled(t, "Notify", mock.Anything)
+}
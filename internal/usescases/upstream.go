@@ -0,0 +1,104 @@
+package usescases
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+)
+
+var (
+	// upstreamRunPattern finds the pipeline and the run of a pipeline resource in its url, like .../_apis/Pipelines/12/runs/345
+	upstreamRunPattern = regexp.MustCompile(`(?i)/pipelines/(\d+)/runs/(\d+)`)
+)
+
+// upstreamRun is a run of an upstream pipeline consumed through resources.pipelines
+type upstreamRun struct {
+	PipelineId int
+	RunId      int
+}
+
+// getUpstreamWorkItemIds returns the work items linked to the upstream runs consumed by builds[0] since its baseline builds[1]
+// The upstream runs of the upstream runs are followed until depth levels, an upstream that can't be read is logged and skipped
+func (u *AdoUsesCases) getUpstreamWorkItemIds(builds []model.PipelineRuns, depth int) []int {
+	ids := []int{}
+	visited := map[string]bool{}
+	u.collectUpstreamWorkItemIds(builds[0], builds[1], depth, visited, &ids)
+	return ids
+}
+
+func (u *AdoUsesCases) collectUpstreamWorkItemIds(run, baseline model.PipelineRuns, depth int, visited map[string]bool, ids *[]int) {
+	if depth <= 0 || run.Resources == nil || baseline.Resources == nil {
+		return
+	}
+
+	aliases := make([]string, 0, len(run.Resources.Pipelines))
+	for alias := range run.Resources.Pipelines {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+
+	for _, alias := range aliases {
+		to, ok := parseUpstreamRun(run.Resources.Pipelines[alias])
+		if !ok {
+			continue
+		}
+		from, ok := parseUpstreamRun(baseline.Resources.Pipelines[alias])
+		if !ok || from.PipelineId != to.PipelineId || from.RunId == to.RunId {
+			continue
+		}
+		key := fmt.Sprintf("%d-%d-%d", to.PipelineId, from.RunId, to.RunId)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+
+		references, err := u.Repository.GetBuildWorkItem(from.RunId, to.RunId)
+		if err != nil {
+			u.logUpstreamWarn(err, alias, "GetBuildWorkItem")
+			continue
+		}
+		for _, reference := range references {
+			if id, err := strconv.Atoi(reference.Id); err == nil {
+				*ids = append(*ids, id)
+			}
+		}
+
+		if depth == 1 {
+			continue
+		}
+		upstreamTo, err := u.Repository.GetPipelineRun(to.PipelineId, to.RunId)
+		if err != nil {
+			u.logUpstreamWarn(err, alias, "GetPipelineRun")
+			continue
+		}
+		upstreamFrom, err := u.Repository.GetPipelineRun(from.PipelineId, from.RunId)
+		if err != nil {
+			u.logUpstreamWarn(err, alias, "GetPipelineRun")
+			continue
+		}
+		u.collectUpstreamWorkItemIds(*upstreamTo, *upstreamFrom, depth-1, visited, ids)
+	}
+}
+
+func parseUpstreamRun(resource model.PipelineResource) (upstreamRun, bool) {
+	match := upstreamRunPattern.FindStringSubmatch(resource.Pipeline.Url)
+	if match == nil {
+		return upstreamRun{}, false
+	}
+	pipelineId, _ := strconv.Atoi(match[1])
+	runId, _ := strconv.Atoi(match[2])
+	return upstreamRun{PipelineId: pipelineId, RunId: runId}, true
+}
+
+func (u *AdoUsesCases) logUpstreamWarn(err error, alias string, action string) {
+	if u.Logger == nil {
+		return
+	}
+	u.Logger.Warn().
+		Err(err).
+		Str("pipeline-resource", alias).
+		Msg(action)
+}
@@ -0,0 +1,81 @@
+package usescases
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Damien-Venant/prev-updater/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func createUpstreamRun(id int, alias string, pipelineId int, runId int) model.PipelineRuns {
+	run := createPipelineRun("refs/heads/main", "", id)
+	run.Resources.Pipelines = map[string]model.PipelineResource{
+		alias: {
+			Pipeline: model.PipelineReference{
+				Id:  runId,
+				Url: fmt.Sprintf("https://dev.azure.com/org/project-id/_apis/Pipelines/%d/runs/%d?revision=1", pipelineId, runId),
+			},
+		},
+	}
+	return run
+}
+
+func TestGetUpstreamWorkItemIds(t *testing.T) {
+	builds := []model.PipelineRuns{
+		createUpstreamRun(10, "component", 5, 50),
+		createUpstreamRun(9, "component", 5, 48),
+	}
+	tests := map[string]struct {
+		depth    int
+		expected []int
+	}{
+		"without upstream": {depth: 0, expected: []int{}},
+		"direct upstream":  {depth: 1, expected: []int{7}},
+		"recursive":        {depth: 3, expected: []int{7, 8}},
+	}
+
+	for name, test := range tests {
+		t.Run("TestGetUpstreamWorkItemIds_"+name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			uc := &AdoUsesCases{Repository: mockRepo}
+			mockRepo.On("GetBuildWorkItem", 48, 50).Return([]model.BuildWorkItems{{Id: "7"}}, nil)
+			mockRepo.On("GetPipelineRun", 5, 50).Return(createUpstreamRun(50, "library", 3, 30), nil)
+			mockRepo.On("GetPipelineRun", 5, 48).Return(createUpstreamRun(48, "library", 3, 29), nil)
+			mockRepo.On("GetBuildWorkItem", 29, 30).Return([]model.BuildWorkItems{{Id: "8"}, {Id: "7"}}, nil)
+			mockRepo.On("GetPipelineRun", 3, mock.Anything).Return(createPipelineRun("refs/heads/main", "", 30), nil)
+
+			ids := uc.getUpstreamWorkItemIds(builds, test.depth)
+
+			slices.Sort(ids)
+			assert.Equal(t, test.expected, slices.Compact(ids))
+		})
+	}
+}
+
+func TestGetUpstreamWorkItemIds_ShouldSkipUnchangedUpstream(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	builds := []model.PipelineRuns{
+		createUpstreamRun(10, "component", 5, 50),
+		createUpstreamRun(9, "component", 5, 50),
+	}
+
+	ids := uc.getUpstreamWorkItemIds(builds, 2)
+
+	assert.Empty(t, ids)
+	mockRepo.AssertNotCalled(t, "GetBuildWorkItem", mock.Anything, mock.Anything)
+}
+
+func TestAppendWorkItemsById_ShouldAddUpstreamWorkItems(t *testing.T) {
+	mockRepo := new(MockRepository)
+	uc := &AdoUsesCases{Repository: mockRepo}
+	mockRepo.On("GetWorkItem", "8").Return(createWorkItem(8, map[string]interface{}{}), nil)
+
+	workItems := uc.appendWorkItemsById([]model.WorkItem{createWorkItem(7, nil)}, []int{8, 7, 8})
+
+	assert.Equal(t, []int{7, 8}, []int{workItems[0].Id, workItems[1].Id})
+	mockRepo.AssertNumberOfCalls(t, "GetWorkItem", 1)
+}
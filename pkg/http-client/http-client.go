@@ -27,6 +27,9 @@ const (
 )
 
 func New(baseUrl string, headers http.Header, logger *zerolog.Logger) *HttpClient {
+	if headers == nil {
+		headers = http.Header{}
+	}
 	return &HttpClient{
 		BaseUrl: baseUrl,
 		Headers: headers,
@@ -36,7 +39,7 @@ func New(baseUrl string, headers http.Header, logger *zerolog.Logger) *HttpClien
 }
 
 func (h *HttpClient) Get(path string, headers http.Header) (*http.Response, error) {
-	url := fmt.Sprintf(formatUrl, h.BaseUrl, path)
+	url := h.requestUrl(path)
 	h.logger.
 		Info().
 		Dict("request-data", zerolog.Dict().Str("url", url).Str("method", "GET")).
@@ -50,14 +53,14 @@ func (h *HttpClient) Get(path string, headers http.Header) (*http.Response, erro
 			Send()
 		return nil, err
 	}
-	request.Header = h.Headers
+	request.Header = h.Headers.Clone()
 	setHeader(request, headers)
 
 	return h.client.Do(request)
 }
 
 func (h *HttpClient) Patch(path string, body []byte, headers http.Header) (*http.Response, error) {
-	url := fmt.Sprintf("%s/%s", h.BaseUrl, path)
+	url := h.requestUrl(path)
 	h.logger.
 		Info().
 		Dict("request-data", zerolog.Dict().Str("url", url).Str("method", "PATCH").Str("body", string(body))).
@@ -71,7 +74,7 @@ func (h *HttpClient) Patch(path string, body []byte, headers http.Header) (*http
 			Send()
 		return nil, err
 	}
-	request.Header = h.Headers
+	request.Header = h.Headers.Clone()
 	request.Header.Set("Content-Type", "application/json-patch+json")
 	setHeader(request, headers)
 
@@ -79,7 +82,7 @@ func (h *HttpClient) Patch(path string, body []byte, headers http.Header) (*http
 }
 
 func (h *HttpClient) Post(path string, body []byte, headers http.Header) (*http.Response, error) {
-	url := fmt.Sprintf("%s/%s", h.BaseUrl, path)
+	url := h.requestUrl(path)
 	h.logger.
 		Info().
 		Dict("request-data", zerolog.Dict().Str("url", url).Str("method", "POST").Str("body", string(body))).
@@ -94,13 +97,25 @@ func (h *HttpClient) Post(path string, body []byte, headers http.Header) (*http.
 			Send()
 		return nil, err
 	}
-	request.Header = h.Headers
-	request.Header.Set("Content-Type", "application/json")
+	request.Header = h.Headers.Clone()
+	if headers.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json")
+	} else {
+		request.Header.Del("Content-Type")
+	}
 
 	setHeader(request, headers)
 	return h.client.Do(request)
 }
 
+// requestUrl joins the base url and the path, an empty path targets the base url itself (e.g. a webhook)
+func (h *HttpClient) requestUrl(path string) string {
+	if path == "" {
+		return h.BaseUrl
+	}
+	return fmt.Sprintf(formatUrl, h.BaseUrl, path)
+}
+
 func setHeader(request *http.Request, headers http.Header) {
 	for key, values := range headers {
 		for _, val := range values {
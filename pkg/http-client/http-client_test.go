@@ -133,3 +133,64 @@ func TestHttpClient_Patch(t *testing.T) {
 	reqBody, _ := ioutil.ReadAll(mock.req.Body)
 	assert.Equal(t, string(body), string(reqBody))
 }
+
+func TestHttpClient_PostWithEmptyPath(t *testing.T) {
+	mockResp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		Header:     make(http.Header),
+	}
+
+	mock := &mockRoundTripper{resp: mockResp}
+
+	client := newTestHttpClient(t, mock)
+	client.BaseUrl = "http://localhost/webhook/abc"
+
+	_, err := client.Post("", []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost/webhook/abc", mock.req.URL.String())
+	assert.Equal(t, "application/json", mock.req.Header.Get("Content-Type"))
+}
+
+func TestHttpClient_ShouldNotShareRequestHeaders(t *testing.T) {
+	mockResp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		Header:     make(http.Header),
+	}
+
+	mock := &mockRoundTripper{resp: mockResp}
+
+	client := newTestHttpClient(t, mock)
+	customHeaders := http.Header{}
+	customHeaders.Set("X-Signature", "first")
+
+	_, err := client.Post("path", []byte(`{}`), customHeaders)
+	require.NoError(t, err)
+	customHeaders.Set("X-Signature", "second")
+	_, err = client.Post("path", []byte(`{}`), customHeaders)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"second"}, mock.req.Header.Values("X-Signature"))
+	assert.Empty(t, client.Headers.Get("X-Signature"))
+}
+
+func TestHttpClient_PostWithContentType(t *testing.T) {
+	mockResp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+		Header:     make(http.Header),
+	}
+
+	mock := &mockRoundTripper{resp: mockResp}
+
+	client := newTestHttpClient(t, mock)
+	customHeaders := http.Header{}
+	customHeaders.Set("Content-Type", "application/cloudevents+json")
+
+	_, err := client.Post("path", []byte(`{}`), customHeaders)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"application/cloudevents+json"}, mock.req.Header.Values("Content-Type"))
+}
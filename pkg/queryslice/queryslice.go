@@ -2,6 +2,7 @@ package queryslice
 
 import (
 	"slices"
+	"sync"
 )
 
 type Predicate[T any] func(predicate T) bool
@@ -58,6 +59,28 @@ func TransformParallel[T any, K any](source []T, transFunc Trans[T, K]) []K {
 	return result
 }
 
+// TransformParallelLimit is TransformParallel with at most limit transformations running at the same time
+// Use it for API calls on large slices, so the API doesn't throttle the requests
+func TransformParallelLimit[T any, K any](source []T, limit int, transFunc Trans[T, K]) []K {
+	if limit <= 0 {
+		return TransformParallel(source, transFunc)
+	}
+	result := make([]K, len(source))
+	semaphore := make(chan struct{}, limit)
+	var waitGroup sync.WaitGroup
+	for index, val := range source {
+		waitGroup.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer waitGroup.Done()
+			result[index] = transFunc(val, index)
+			<-semaphore
+		}()
+	}
+	waitGroup.Wait()
+	return result
+}
+
 func FindIndex[T any](source []T, filterFunc Predicate[T]) int {
 	for index, val := range source {
 		if filterFunc(val) {
@@ -3,6 +3,7 @@ package queryslice
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -139,6 +140,27 @@ func TestQuerySliceTransformParallel(t *testing.T) {
 	}
 }
 
+func TestQuerySliceTransformParallelLimit(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	values := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	result := TransformParallelLimit(values, 3, func(val int, _ int) int {
+		current := running.Add(1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * time.Duration(rand.Intn(20)))
+		running.Add(-1)
+		return val * 2
+	})
+
+	assert.Equal(t, []int{2, 4, 6, 8, 10, 12, 14, 16}, result)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+}
+
 func TestQuerySliceFindIndex(t *testing.T) {
 	tests := []struct {
 		name      string